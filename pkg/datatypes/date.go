@@ -35,6 +35,11 @@ func (date Date) GormDataType() string {
 	return "date"
 }
 
+// SQLType is the column type of Date
+func (date Date) SQLType() string {
+	return "date"
+}
+
 func (date Date) GobEncode() ([]byte, error) {
 	return time.Time(date).GobEncode()
 }
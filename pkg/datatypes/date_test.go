@@ -0,0 +1,63 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDateScanValue(t *testing.T) {
+	var date Date
+	if err := date.Scan(time.Date(2022, 6, 1, 14, 30, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	if date.String() != "2022-06-01" {
+		t.Errorf("Scan() = %s, want 2022-06-01", date)
+	}
+
+	value, err := date.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	if got, ok := value.(time.Time); !ok || !got.Equal(want) {
+		t.Errorf("Value() = %v, want %v", value, want)
+	}
+
+	if err := date.Scan(nil); err != nil || !time.Time(date).IsZero() {
+		t.Errorf("Scan(nil) = %s, %v, want the zero date", date, err)
+	}
+}
+
+func TestDateJSON(t *testing.T) {
+	date, err := Date{}.FromString("2022-06-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(date)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `"2022-06-01"` {
+		t.Errorf("Marshal = %s, want \"2022-06-01\"", data)
+	}
+
+	var got Date
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.String() != "2022-06-01" {
+		t.Errorf("Unmarshal(%s) = %s", data, got)
+	}
+
+	for _, invalid := range []string{`"01/06/2022"`, `"2022-06-01T00:00:00Z"`, `20220601`} {
+		if err := json.Unmarshal([]byte(invalid), &got); err == nil {
+			t.Errorf("Unmarshal(%s) returned no error", invalid)
+		}
+	}
+}
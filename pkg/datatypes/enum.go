@@ -0,0 +1,16 @@
+package datatypes
+
+// Enum is implemented by string types that are stored as postgres enum types.
+//
+// AutoMigrate creates the enum type, named after the Go type in snake_case,
+// before the tables that use it. Values are scanned and stored as plain strings,
+// so the type only needs an underlying string type.
+//
+//	type OrderStatus string
+//
+//	func (OrderStatus) EnumValues() []string {
+//		return []string{"pending", "shipped", "delivered"}
+//	}
+type Enum interface {
+	EnumValues() []string
+}
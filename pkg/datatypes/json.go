@@ -6,10 +6,55 @@
 package datatypes
 
 import (
+	"bytes"
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 )
 
+var (
+	// Encodes JSON values, see SetJSONCodec
+	jsonMarshal = json.Marshal
+
+	// Decodes JSON values, see SetJSONCodec
+	jsonUnmarshal = json.Unmarshal
+)
+
+// SetJSONCodec sets the functions used by JSON.Value and JSON.Scan to encode and decode
+// JSON e.g from jsoniter or a decoder with UseNumber. A nil function resets it to encoding/json.
+// It is not safe to call concurrently with queries, call it once at startup.
+func SetJSONCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) {
+	if marshal == nil {
+		marshal = json.Marshal
+	}
+
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+
+	jsonMarshal, jsonUnmarshal = marshal, unmarshal
+}
+
+// UnmarshalUseNumber decodes data into v like json.Unmarshal but numbers are decoded
+// as json.Number instead of float64, so large integers e.g 64-bit ids keep their precision.
+// Use it with SetJSONCodec(nil, datatypes.UnmarshalUseNumber).
+//
+// The trade-off is that numbers in JSON values are then json.Number strings and must be
+// converted with their Int64 or Float64 methods instead of type asserted to float64.
+func UnmarshalUseNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+
+	// Like json.Unmarshal, data must be a single JSON value
+	if decoder.More() {
+		return errors.New("invalid character after top-level JSON value")
+	}
+	return nil
+}
+
 // Custom JSON data type that implements the sql.Scanner and driver.Valuer interfaces
 // to work with postgres database.
 type JSON map[string]interface{}
@@ -21,7 +66,7 @@ func (j *JSON) Scan(value interface{}) error {
 		return nil
 	}
 
-	if err := json.Unmarshal(value.([]byte), &j); err != nil {
+	if err := jsonUnmarshal(value.([]byte), &j); err != nil {
 		return err
 	}
 	return nil
@@ -31,6 +76,6 @@ func (j *JSON) Scan(value interface{}) error {
 //
 // Implement driver.Valuer interface
 func (j JSON) Value() (driver.Value, error) {
-	valueString, err := json.Marshal(j)
+	valueString, err := jsonMarshal(j)
 	return string(valueString), err
 }
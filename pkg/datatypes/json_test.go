@@ -0,0 +1,98 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestJSONScanValue(t *testing.T) {
+	j := JSON{"name": "John", "age": float64(30), "tags": []interface{}{"a", "b"}}
+	value, err := j.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got JSON
+	if err := got.Scan([]byte(value.(string))); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, j) {
+		t.Errorf("Scan(Value()) = %v, want %v", got, j)
+	}
+
+	if err := got.Scan(nil); err != nil || got != nil {
+		t.Errorf("Scan(nil) = %v, %v, want nil", got, err)
+	}
+
+	if err := got.Scan([]byte("{")); err == nil {
+		t.Error("Scan of invalid JSON returned no error")
+	}
+}
+
+func TestSetJSONCodec(t *testing.T) {
+	t.Cleanup(func() { SetJSONCodec(nil, nil) })
+
+	errCodec := errors.New("codec")
+	SetJSONCodec(
+		func(v interface{}) ([]byte, error) { return nil, errCodec },
+		func(data []byte, v interface{}) error { return errCodec },
+	)
+
+	if _, err := (JSON{}).Value(); err != errCodec {
+		t.Errorf("Value() error = %v, want the codec error", err)
+	}
+
+	var j JSON
+	if err := j.Scan([]byte("{}")); err != errCodec {
+		t.Errorf("Scan() error = %v, want the codec error", err)
+	}
+
+	// nil functions reset the codec to encoding/json
+	SetJSONCodec(nil, nil)
+	if err := j.Scan([]byte(`{"a":1}`)); err != nil || j["a"] != float64(1) {
+		t.Errorf("Scan() after reset = %v, %v", j, err)
+	}
+}
+
+func TestUnmarshalUseNumber(t *testing.T) {
+	t.Cleanup(func() { SetJSONCodec(nil, nil) })
+	SetJSONCodec(nil, UnmarshalUseNumber)
+
+	var j JSON
+	if err := j.Scan([]byte(`{"id":9007199254740993,"price":1.25}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	id, ok := j["id"].(json.Number)
+	if !ok {
+		t.Fatalf("id has type %T, want json.Number", j["id"])
+	}
+
+	if n, err := id.Int64(); err != nil || n != 9007199254740993 {
+		t.Errorf("id = %v, %v, want 9007199254740993", n, err)
+	}
+
+	if j["price"] != json.Number("1.25") {
+		t.Errorf("price = %v, want 1.25", j["price"])
+	}
+
+	tests := []struct {
+		data    string
+		wantErr bool
+	}{
+		{`{"a":1}`, false},
+		{`{"a":1} `, false},
+		{`{"a":1}{"b":2}`, true},
+		{`{"a":`, true},
+	}
+
+	for _, tt := range tests {
+		var v map[string]interface{}
+		if err := UnmarshalUseNumber([]byte(tt.data), &v); (err != nil) != tt.wantErr {
+			t.Errorf("UnmarshalUseNumber(%s) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+		}
+	}
+}
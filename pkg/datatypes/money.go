@@ -0,0 +1,163 @@
+package datatypes
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of money in the minor units of its ISO 4217 currency
+// e.g Money{Amount: 1234, Currency: "USD"} is 12.34 USD.
+//
+// It's stored in a money_amount composite column (amount numeric, currency char(3))
+// that is created by AutoMigrate, so amounts never go through float64.
+type Money struct {
+	// Amount in minor units e.g cents
+	Amount int64
+
+	// ISO 4217 currency code e.g USD
+	Currency string
+}
+
+// Number of minor unit digits of currencies that don't have 2 e.g 1 JPY has no cents
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Returns the number of minor unit digits of the currency
+func (m Money) exponent() int {
+	if exp, ok := currencyExponents[strings.ToUpper(m.Currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Returns the amount in major units e.g 12.34 for 1234 cents
+func (m Money) Decimal() string {
+	exp := m.exponent()
+	digits := strconv.FormatInt(m.Amount, 10)
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	if exp == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// Sets the amount from the amount in major units e.g 12.34.
+// Returns an error if the amount has more digits than the minor units of the currency.
+func (m *Money) setDecimal(s string) error {
+	exp := m.exponent()
+	s = strings.TrimSpace(s)
+
+	sign := int64(1)
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, s[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if fraction = strings.TrimRight(fraction, "0"); len(fraction) > exp {
+		return fmt.Errorf("amount %s has more than %d decimal places for %s", s, exp, m.Currency)
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+
+	amount, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exp-len(fraction)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %s: %w", s, err)
+	}
+
+	m.Amount = sign * amount
+	return nil
+}
+
+// String returns the amount and currency e.g 12.34 USD
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Scan scans a money_amount composite e.g (12.34,USD) into m, implements sql.Scanner interface
+func (m *Money) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+
+	amount, currency, found := strings.Cut(strings.Trim(s, "()"), ",")
+	if !found {
+		return fmt.Errorf("invalid money value %q", s)
+	}
+
+	money := Money{Currency: strings.TrimSpace(strings.Trim(currency, `"`))}
+	if err := money.setDecimal(amount); err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
+
+// Value returns the money_amount composite e.g (12.34,USD), implements driver.Valuer interface
+func (m Money) Value() (driver.Value, error) {
+	return fmt.Sprintf("(%s,%s)", m.Decimal(), m.Currency), nil
+}
+
+// SQLType is the column type of Money
+func (m Money) SQLType() string {
+	return "money_amount"
+}
+
+// CreateTypeSQL returns the statement creating the money_amount composite type
+func (m Money) CreateTypeSQL() string {
+	return "CREATE TYPE money_amount AS (amount numeric, currency char(3))"
+}
+
+// The JSON encoding of Money. The amount is a string so it's not decoded as a float
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes m as {"amount":"12.34","currency":"USD"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes {"amount":"12.34","currency":"USD"} into m
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	money := Money{Currency: v.Currency}
+	if err := money.setDecimal(v.Amount); err != nil {
+		return err
+	}
+
+	*m = money
+	return nil
+}
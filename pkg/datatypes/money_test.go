@@ -0,0 +1,107 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{Amount: 1234, Currency: "USD"}, "12.34"},
+		{Money{Amount: 5, Currency: "USD"}, "0.05"},
+		{Money{Amount: -5, Currency: "USD"}, "-0.05"},
+		{Money{Amount: 0, Currency: "EUR"}, "0.00"},
+		{Money{Amount: 1500, Currency: "JPY"}, "1500"},
+		{Money{Amount: 1500, Currency: "jpy"}, "1500"},
+		{Money{Amount: 12345, Currency: "KWD"}, "12.345"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("%#v.Decimal() = %s, want %s", tt.money, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    Money
+		wantErr bool
+	}{
+		{"nil", nil, Money{}, false},
+		{"bytes", []byte("(12.34,USD)"), Money{Amount: 1234, Currency: "USD"}, false},
+		{"string", "(-0.50,EUR)", Money{Amount: -50, Currency: "EUR"}, false},
+		{"trailing zeros", "(12.3400,USD)", Money{Amount: 1234, Currency: "USD"}, false},
+		{"no minor units", "(1500,JPY)", Money{Amount: 1500, Currency: "JPY"}, false},
+		{"quoted currency", `(1.00,"USD")`, Money{Amount: 100, Currency: "USD"}, false},
+		{"too many decimals", "(1.234,USD)", Money{}, true},
+		{"decimals for JPY", "(1.5,JPY)", Money{}, true},
+		{"no currency", "(1.00)", Money{}, true},
+		{"invalid amount", "(abc,USD)", Money{}, true},
+		{"unsupported type", 12.34, Money{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := got.Scan(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Scan(%v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyValue(t *testing.T) {
+	value, err := Money{Amount: 1234, Currency: "USD"}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value != "(12.34,USD)" {
+		t.Errorf("Value() = %v, want (12.34,USD)", value)
+	}
+
+	var scanned Money
+	if err := scanned.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+
+	if scanned != (Money{Amount: 1234, Currency: "USD"}) {
+		t.Errorf("Scan(Value()) = %#v", scanned)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	money := Money{Amount: 1999, Currency: "USD"}
+	data, err := json.Marshal(money)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"amount":"19.99","currency":"USD"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	var got Money
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != money {
+		t.Errorf("Unmarshal(%s) = %#v, want %#v", data, got, money)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":"1.999","currency":"USD"}`), &got); err == nil {
+		t.Error("Unmarshal with 3 decimal places for USD returned no error")
+	}
+}
@@ -0,0 +1,145 @@
+package datatypes
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// jsonNull is the JSON encoding of invalid null types
+var jsonNull = []byte("null")
+
+// NullString is a nullable string that marshals to JSON null when invalid.
+// It implements the sql.Scanner and driver.Valuer interfaces through sql.NullString.
+type NullString struct {
+	sql.NullString
+}
+
+// Create a new valid NullString from s
+func NewNullString(s string) NullString {
+	return NullString{sql.NullString{String: s, Valid: true}}
+}
+
+// Custom Json encoder
+func (n NullString) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.String)
+}
+
+// Custom Json decoder
+func (n *NullString) UnmarshalJSON(data []byte) error {
+	if string(data) == string(jsonNull) {
+		n.String, n.Valid = "", false
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &n.String); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+// NullInt64 is a nullable int64 that marshals to JSON null when invalid.
+// It implements the sql.Scanner and driver.Valuer interfaces through sql.NullInt64.
+type NullInt64 struct {
+	sql.NullInt64
+}
+
+// Create a new valid NullInt64 from i
+func NewNullInt64(i int64) NullInt64 {
+	return NullInt64{sql.NullInt64{Int64: i, Valid: true}}
+}
+
+// Custom Json encoder
+func (n NullInt64) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.Int64)
+}
+
+// Custom Json decoder
+func (n *NullInt64) UnmarshalJSON(data []byte) error {
+	if string(data) == string(jsonNull) {
+		n.Int64, n.Valid = 0, false
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &n.Int64); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+// NullBool is a nullable bool that marshals to JSON null when invalid.
+// It implements the sql.Scanner and driver.Valuer interfaces through sql.NullBool.
+type NullBool struct {
+	sql.NullBool
+}
+
+// Create a new valid NullBool from b
+func NewNullBool(b bool) NullBool {
+	return NullBool{sql.NullBool{Bool: b, Valid: true}}
+}
+
+// Custom Json encoder
+func (n NullBool) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.Bool)
+}
+
+// Custom Json decoder
+func (n *NullBool) UnmarshalJSON(data []byte) error {
+	if string(data) == string(jsonNull) {
+		n.Bool, n.Valid = false, false
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &n.Bool); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+// NullFloat64 is a nullable float64 that marshals to JSON null when invalid.
+// It implements the sql.Scanner and driver.Valuer interfaces through sql.NullFloat64.
+type NullFloat64 struct {
+	sql.NullFloat64
+}
+
+// Create a new valid NullFloat64 from f
+func NewNullFloat64(f float64) NullFloat64 {
+	return NullFloat64{sql.NullFloat64{Float64: f, Valid: true}}
+}
+
+// Custom Json encoder
+func (n NullFloat64) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.Float64)
+}
+
+// Custom Json decoder
+func (n *NullFloat64) UnmarshalJSON(data []byte) error {
+	if string(data) == string(jsonNull) {
+		n.Float64, n.Valid = 0, false
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &n.Float64); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
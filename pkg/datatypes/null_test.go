@@ -0,0 +1,109 @@
+package datatypes
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+)
+
+func TestNullJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"valid string", NewNullString("abc"), `"abc"`},
+		{"null string", NullString{}, `null`},
+		{"valid int", NewNullInt64(42), `42`},
+		{"null int", NullInt64{}, `null`},
+		{"valid bool", NewNullBool(false), `false`},
+		{"null bool", NullBool{}, `null`},
+		{"valid float", NewNullFloat64(1.5), `1.5`},
+		{"null float", NullFloat64{}, `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != tt.want {
+				t.Errorf("Marshal(%v) = %s, want %s", tt.value, data, tt.want)
+			}
+		})
+	}
+}
+
+func TestNullUnmarshalJSON(t *testing.T) {
+	var s NullString
+	if err := json.Unmarshal([]byte(`"abc"`), &s); err != nil || s != NewNullString("abc") {
+		t.Errorf("Unmarshal(\"abc\") = %v, %v", s, err)
+	}
+
+	if err := json.Unmarshal([]byte(`null`), &s); err != nil || s.Valid || s.String != "" {
+		t.Errorf("Unmarshal(null) = %v, %v", s, err)
+	}
+
+	var i NullInt64
+	if err := json.Unmarshal([]byte(`7`), &i); err != nil || i != NewNullInt64(7) {
+		t.Errorf("Unmarshal(7) = %v, %v", i, err)
+	}
+
+	if err := json.Unmarshal([]byte(`"7"`), &i); err == nil {
+		t.Error("Unmarshal(\"7\") into NullInt64 returned no error")
+	}
+
+	var b NullBool
+	if err := json.Unmarshal([]byte(`true`), &b); err != nil || b != NewNullBool(true) {
+		t.Errorf("Unmarshal(true) = %v, %v", b, err)
+	}
+
+	if err := json.Unmarshal([]byte(`null`), &b); err != nil || b.Valid {
+		t.Errorf("Unmarshal(null) = %v, %v", b, err)
+	}
+
+	var f NullFloat64
+	if err := json.Unmarshal([]byte(`2.25`), &f); err != nil || f != NewNullFloat64(2.25) {
+		t.Errorf("Unmarshal(2.25) = %v, %v", f, err)
+	}
+}
+
+func TestNullScanValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface {
+			Scan(interface{}) error
+			Value() (driver.Value, error)
+		}
+		src  interface{}
+		want driver.Value
+	}{
+		{"string", &NullString{}, "abc", "abc"},
+		{"null string", &NullString{}, nil, nil},
+		{"int", &NullInt64{}, int64(42), int64(42)},
+		{"null int", &NullInt64{}, nil, nil},
+		{"bool", &NullBool{}, true, true},
+		{"null bool", &NullBool{}, nil, nil},
+		{"float", &NullFloat64{}, 1.5, 1.5},
+		{"null float", &NullFloat64{}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.value.Scan(tt.src); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tt.value.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Value() after Scan(%v) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package datatypes
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Custom time type to support time of day of the format "15:04:05".
+//
+// Create a new time instance with Time.FromString
+type Time time.Time
+
+// The time of day format
+const timeLayout = "15:04:05"
+
+// Satisfy database Scanner interface
+func (t *Time) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = Time{}
+		return nil
+	case time.Time:
+		*t = NewTime(v.Hour(), v.Minute(), v.Second())
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	}
+
+	return fmt.Errorf("cannot scan %T into Time", value)
+}
+
+// Parses time of day s ignoring fractional seconds
+func (t *Time) parse(s string) error {
+	parsed, err := time.Parse("15:04:05.999999999", s)
+	if err != nil {
+		return err
+	}
+
+	*t = NewTime(parsed.Hour(), parsed.Minute(), parsed.Second())
+	return nil
+}
+
+// Satisfy database Valuer interface
+func (t Time) Value() (driver.Value, error) {
+	return t.String(), nil
+}
+
+// GormDataType gorm common data type
+func (t Time) GormDataType() string {
+	return "time"
+}
+
+// SQLType is the column type of Time
+func (t Time) SQLType() string {
+	return "time"
+}
+
+// Custom Json encoder
+// Called when go types are being converted to json strings
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("\"%s\"", t.String())), nil
+}
+
+// Custom Json decoder
+// Called to convert json strings to go types
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("time should be a string, got %v", data)
+	}
+
+	// Make sure that the user has provided the standard time format
+	parsed, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return fmt.Errorf("time should be of the format: hh:mm:ss")
+	}
+
+	*t = Time(parsed)
+	return nil
+}
+
+// Returns the hour for t
+func (t Time) Hour() int {
+	return time.Time(t).Hour()
+}
+
+// Returns the minute for t
+func (t Time) Minute() int {
+	return time.Time(t).Minute()
+}
+
+// Returns the second for t
+func (t Time) Second() int {
+	return time.Time(t).Second()
+}
+
+// Stringer interface for time
+// Of the format 14:30:00
+func (t Time) String() string {
+	return fmt.Sprintf("%02d:%02d:%02d", t.Hour(), t.Minute(), t.Second())
+}
+
+// Create a new time of day from hour, minute and second
+func NewTime(hour, minute, second int) Time {
+	return Time(time.Date(0, time.January, 1, hour, minute, second, 0, time.UTC))
+}
+
+// FromString creates a new Time object from a time string.
+//
+// If t is not of format matching layout: "15:04:05", it returns an error
+func (Time) FromString(t string) (Time, error) {
+	parsed, err := time.Parse(timeLayout, t)
+	if err != nil {
+		return Time{}, err
+	}
+	return Time(parsed), nil
+}
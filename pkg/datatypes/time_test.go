@@ -0,0 +1,90 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    string
+		wantErr bool
+	}{
+		{"nil", nil, "00:00:00", false},
+		{"time.Time", time.Date(2022, 5, 1, 14, 30, 15, 500, time.UTC), "14:30:15", false},
+		{"bytes", []byte("08:05:09"), "08:05:09", false},
+		{"string", "23:59:59", "23:59:59", false},
+		{"fractional seconds", "10:20:30.123456", "10:20:30", false},
+		{"invalid string", "25:00", "", true},
+		{"unsupported type", 42, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Time
+			err := got.Scan(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("Scan(%v) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeValue(t *testing.T) {
+	value, err := NewTime(9, 5, 0).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value != "09:05:00" {
+		t.Errorf("Value() = %v, want 09:05:00", value)
+	}
+}
+
+func TestTimeJSON(t *testing.T) {
+	data, err := json.Marshal(NewTime(14, 30, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `"14:30:00"` {
+		t.Errorf("Marshal = %s, want \"14:30:00\"", data)
+	}
+
+	var got Time
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != NewTime(14, 30, 0) {
+		t.Errorf("Unmarshal(%s) = %s, want 14:30:00", data, got)
+	}
+
+	for _, invalid := range []string{`"2:30"`, `1430`, `"14:30"`} {
+		if err := json.Unmarshal([]byte(invalid), &got); err == nil {
+			t.Errorf("Unmarshal(%s) returned no error", invalid)
+		}
+	}
+}
+
+func TestTimeFromString(t *testing.T) {
+	got, err := Time{}.FromString("07:45:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Hour() != 7 || got.Minute() != 45 || got.Second() != 30 {
+		t.Errorf("FromString(07:45:30) = %s", got)
+	}
+
+	if _, err := (Time{}).FromString("7pm"); err == nil {
+		t.Error("FromString(7pm) returned no error")
+	}
+}
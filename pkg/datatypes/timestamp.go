@@ -0,0 +1,100 @@
+package datatypes
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// LocalTimestamp is a date and time of day without a time zone e.g 2022-06-01 14:30:00,
+// stored in a timestamp (without time zone) column.
+//
+// The wall clock is stored and scanned as is, it is never converted between time zones.
+// Scanned values have the UTC location. Use time.Time for timestamptz columns.
+type LocalTimestamp time.Time
+
+// The timestamp format without a time zone
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+// Create a new local timestamp with the wall clock of t, ignoring its location
+func NewLocalTimestamp(t time.Time) LocalTimestamp {
+	return LocalTimestamp(time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC))
+}
+
+// Satisfy database Scanner interface
+func (ts *LocalTimestamp) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*ts = LocalTimestamp{}
+		return nil
+	case time.Time:
+		*ts = NewLocalTimestamp(v)
+		return nil
+	case []byte:
+		return ts.parse(string(v))
+	case string:
+		return ts.parse(v)
+	}
+
+	return fmt.Errorf("cannot scan %T into LocalTimestamp", value)
+}
+
+// Parses the timestamp s of the format 2006-01-02 15:04:05 with optional fractional seconds
+func (ts *LocalTimestamp) parse(s string) error {
+	parsed, err := time.Parse("2006-01-02 15:04:05.999999999", s)
+	if err != nil {
+		return err
+	}
+
+	*ts = LocalTimestamp(parsed)
+	return nil
+}
+
+// Satisfy database Valuer interface.
+// The wall clock is sent as text, so the driver does not convert it to UTC.
+func (ts LocalTimestamp) Value() (driver.Value, error) {
+	return ts.String(), nil
+}
+
+// SQLType is the column type of LocalTimestamp
+func (ts LocalTimestamp) SQLType() string {
+	return "timestamp"
+}
+
+// Custom Json encoder e.g "2022-06-01T14:30:00", without a time zone offset
+func (ts LocalTimestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(ts).Format("2006-01-02T15:04:05.999999999"))
+}
+
+// Custom Json decoder. A time zone offset is ignored, the wall clock is kept
+func (ts *LocalTimestamp) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("timestamp should be a string, got %v", data)
+	}
+
+	if parsed, err := time.Parse("2006-01-02T15:04:05.999999999", s); err == nil {
+		*ts = LocalTimestamp(parsed)
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return fmt.Errorf("timestamp should be of the format: yyyy-mm-ddThh:mm:ss")
+	}
+
+	*ts = NewLocalTimestamp(parsed)
+	return nil
+}
+
+// Returns the timestamp as a time.Time with the UTC location
+func (ts LocalTimestamp) Time() time.Time {
+	return time.Time(ts)
+}
+
+// Stringer interface for LocalTimestamp
+// Of the format 2022-06-01 14:30:00
+func (ts LocalTimestamp) String() string {
+	return time.Time(ts).Format(timestampLayout)
+}
@@ -0,0 +1,105 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLocalTimestampScan(t *testing.T) {
+	kampala := time.FixedZone("EAT", 3*60*60)
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    string
+		wantErr bool
+	}{
+		{"nil", nil, "0001-01-01 00:00:00", false},
+		{"time.Time keeps the wall clock", time.Date(2022, 6, 1, 14, 30, 0, 0, kampala), "2022-06-01 14:30:00", false},
+		{"bytes", []byte("2022-06-01 14:30:00"), "2022-06-01 14:30:00", false},
+		{"fractional seconds", "2022-06-01 14:30:00.123456", "2022-06-01 14:30:00.123456", false},
+		{"invalid string", "2022-06-01", "", true},
+		{"unsupported type", 42, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got LocalTimestamp
+			err := got.Scan(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("Scan(%v) = %s, want %s", tt.value, got, tt.want)
+			}
+
+			if got.Time().Location() != time.UTC {
+				t.Errorf("Scan(%v) has location %s, want UTC", tt.value, got.Time().Location())
+			}
+		})
+	}
+}
+
+func TestLocalTimestampValue(t *testing.T) {
+	ts := NewLocalTimestamp(time.Date(2022, 6, 1, 14, 30, 5, 0, time.FixedZone("EST", -5*60*60)))
+	value, err := ts.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value != "2022-06-01 14:30:05" {
+		t.Errorf("Value() = %v, want 2022-06-01 14:30:05", value)
+	}
+
+	var scanned LocalTimestamp
+	if err := scanned.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+
+	if scanned != ts {
+		t.Errorf("Scan(Value()) = %s, want %s", scanned, ts)
+	}
+}
+
+func TestLocalTimestampJSON(t *testing.T) {
+	ts := NewLocalTimestamp(time.Date(2022, 6, 1, 14, 30, 0, 0, time.UTC))
+	data, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `"2022-06-01T14:30:00"` {
+		t.Errorf("Marshal = %s, want \"2022-06-01T14:30:00\"", data)
+	}
+
+	tests := []struct {
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{`"2022-06-01T14:30:00"`, "2022-06-01 14:30:00", false},
+		{`"2022-06-01T14:30:00+03:00"`, "2022-06-01 14:30:00", false},
+		{`"2022-06-01T14:30:00Z"`, "2022-06-01 14:30:00", false},
+		{`"01/06/2022"`, "", true},
+		{`1654093800`, "", true},
+	}
+
+	for _, tt := range tests {
+		var got LocalTimestamp
+		err := json.Unmarshal([]byte(tt.data), &got)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			continue
+		}
+
+		if !tt.wantErr && got.String() != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package datatypes
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// TSVector is a postgres tsvector used for full-text search e.g 'fat':2 'rat':3.
+//
+// It's usually a generated column indexed with GIN and matched with query.FullTextMatch:
+//
+//	Search datatypes.TSVector `orm:"generated:to_tsvector('english', title || ' ' || body);index:gin"`
+type TSVector string
+
+// Scan scans a tsvector into t, implements sql.Scanner interface
+func (t *TSVector) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = ""
+		return nil
+	case []byte:
+		*t = TSVector(v)
+		return nil
+	case string:
+		*t = TSVector(v)
+		return nil
+	}
+
+	return fmt.Errorf("cannot scan %T into TSVector", value)
+}
+
+// Value returns the tsvector text, implements driver.Valuer interface
+func (t TSVector) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+// SQLType is the column type of TSVector
+func (t TSVector) SQLType() string {
+	return "tsvector"
+}
@@ -0,0 +1,34 @@
+package datatypes
+
+import "testing"
+
+func TestTSVectorScanValue(t *testing.T) {
+	for _, value := range []interface{}{"'fat':2 'rat':3", []byte("'fat':2 'rat':3")} {
+		var v TSVector
+		if err := v.Scan(value); err != nil {
+			t.Fatal(err)
+		}
+
+		if v != "'fat':2 'rat':3" {
+			t.Errorf("Scan(%T) = %q", value, v)
+		}
+	}
+
+	v := TSVector("'rat':1")
+	if err := v.Scan(nil); err != nil || v != "" {
+		t.Errorf("Scan(nil) = %q, %v", v, err)
+	}
+
+	if err := v.Scan(1); err == nil {
+		t.Error("Scan(1) returned no error")
+	}
+
+	value, err := TSVector("'cat':1").Value()
+	if err != nil || value != "'cat':1" {
+		t.Errorf("Value() = %v, %v", value, err)
+	}
+
+	if got := (TSVector("")).SQLType(); got != "tsvector" {
+		t.Errorf("SQLType() = %s, want tsvector", got)
+	}
+}
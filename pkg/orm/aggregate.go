@@ -0,0 +1,137 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+	"github.com/abiiranathan/gosqlorm/pkg/schema"
+)
+
+// Returns the sum of column for rows of model matching filter.
+// Returns 0 if no rows match.
+func (o *orm) Sum(model interface{}, column string, filter *query.QueryFilter) (float64, error) {
+	var result sql.NullFloat64
+	err := o.aggregate("SUM", model, column, filter, &result)
+	return result.Float64, err
+}
+
+// Returns the average of column for rows of model matching filter.
+// Returns 0 if no rows match.
+func (o *orm) Avg(model interface{}, column string, filter *query.QueryFilter) (float64, error) {
+	var result sql.NullFloat64
+	err := o.aggregate("AVG", model, column, filter, &result)
+	return result.Float64, err
+}
+
+// Scans the minimum value of column for rows of model matching filter into dest,
+// a pointer to a value of the column type e.g *int or *time.Time.
+//
+// The minimum is NULL if no rows match, use a pointer to a pointer
+// or a sql.Null type e.g *sql.NullTime to tell it apart.
+func (o *orm) Min(model interface{}, column string, dest interface{}, filter *query.QueryFilter) error {
+	return o.aggregate("MIN", model, column, filter, dest)
+}
+
+// Scans the maximum value of column for rows of model matching filter into dest.
+// See Min for the types of dest.
+func (o *orm) Max(model interface{}, column string, dest interface{}, filter *query.QueryFilter) error {
+	return o.aggregate("MAX", model, column, filter, dest)
+}
+
+// Runs the aggregate function fn over column of the model's table and scans the result into dest.
+//
+// column is converted to snake_case and must be a column of model.
+// Aggregates over an empty set are NULL in sql.
+func (o *orm) aggregate(fn string, model interface{}, column string, filter *query.QueryFilter, dest interface{}) error {
+	tblSchema, err := o.tableSchema(model)
+	if err != nil {
+		return err
+	}
+
+	column = schema.SnakeCase(column)
+	if tblSchema.FieldByColumn(column) == nil {
+		return fmt.Errorf("column %s does not exist in table %s", column, tblSchema.TableName)
+	}
+
+	// Qualify the column so it's not ambiguous with joined tables
+	column = fmt.Sprintf("%s.%s", tblSchema.ColumnQualifier(), column)
+	selectQuery := fmt.Sprintf("SELECT %s(%s) FROM %s%s ", fn, column, tblSchema.QualifiedName(), filter.JoinClause())
+
+	q := o.newReadQuery(selectQuery, dest, filter)
+	q.Label = queryLabel(model, strings.ToUpper(fn[:1])+strings.ToLower(fn[1:]))
+	return q.ScanOne()
+}
+
+// Returns the number of rows of model matching filter. filter may be nil
+func (o *orm) Count(model interface{}, filter *query.QueryFilter) (int64, error) {
+	return o.count(model, "*", filter)
+}
+
+// Returns the number of distinct non-null values of column for rows of model matching filter.
+// column is converted to snake_case and must be a column of model.
+func (o *orm) CountDistinct(model interface{}, column string, filter *query.QueryFilter) (int64, error) {
+	tblSchema, err := o.tableSchema(model)
+	if err != nil {
+		return 0, err
+	}
+
+	column = schema.SnakeCase(column)
+	if tblSchema.FieldByColumn(column) == nil {
+		return 0, fmt.Errorf("column %s does not exist in table %s", column, tblSchema.TableName)
+	}
+
+	// Qualify the column so it's not ambiguous with joined tables
+	column = fmt.Sprintf("%s.%s", tblSchema.ColumnQualifier(), column)
+	return o.count(model, "DISTINCT "+column, filter)
+}
+
+// Counts expr e.g * for rows of model matching filter.
+// Ordering, limit and offset of the filter are ignored.
+func (o *orm) count(model interface{}, expr string, filter *query.QueryFilter) (int64, error) {
+	tblSchema, err := o.tableSchema(model)
+	if err != nil {
+		return 0, err
+	}
+
+	sql, args, err := countStatement(tblSchema, o.config.Driver.String(), expr, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	q := o.newReadQuery(sql, &total, nil, args...)
+	q.Label = queryLabel(model, "Count")
+
+	if err := q.ScanOne(); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// Returns the statement counting expr for the rows of the table matching filter and its args.
+//
+// COUNT(*) of grouped rows returns a row per group, so a filter with GroupBy
+// counts the groups in a subquery. Distinct values of grouped rows can't be counted.
+func countStatement(tblSchema *schema.TableSchema, driver, expr string, filter *query.QueryFilter) (string, []interface{}, error) {
+	countFilter := filter.Clone()
+	countFilter.OrderBy = ""
+	countFilter.Limit = 0
+	countFilter.Offset = 0
+
+	if len(countFilter.GroupBy) == 0 {
+		q := &query.Query{Driver: driver, Query: fmt.Sprintf("SELECT COUNT(%s) FROM %s%s ", expr, tblSchema.QualifiedName(), countFilter.JoinClause()), Filter: countFilter}
+		q.AddQueryFilters()
+		return q.Query, q.Args, nil
+	}
+
+	if expr != "*" {
+		return "", nil, fmt.Errorf("can't count %s of grouped rows", expr)
+	}
+
+	q := &query.Query{Driver: driver, Query: fmt.Sprintf("SELECT 1 FROM %s%s ", tblSchema.QualifiedName(), countFilter.JoinClause()), Filter: countFilter}
+	q.AddQueryFilters()
+	return fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS grouped", q.Query), q.Args, nil
+}
@@ -0,0 +1,70 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+	"github.com/abiiranathan/gosqlorm/pkg/schema"
+)
+
+type countOrder struct {
+	ID         int `orm:"primaryKey;autoIncrement"`
+	CustomerID int
+	Total      int
+}
+
+func TestCountStatement(t *testing.T) {
+	tblSchema, err := schema.GetTableSchema(&countOrder{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		filter  *query.QueryFilter
+		sql     string
+		args    []interface{}
+		wantErr bool
+	}{
+		{"nil filter", "*", nil, "SELECT COUNT(*) FROM public.count_orders ", nil, false},
+		{
+			"page filter",
+			"*",
+			&query.QueryFilter{Where: "total > $1", Args: query.Args{10}, OrderBy: "id", Limit: 5, Offset: 10},
+			"SELECT COUNT(*) FROM public.count_orders  WHERE total > $1",
+			[]interface{}{10},
+			false,
+		},
+		{
+			"grouped",
+			"*",
+			&query.QueryFilter{Where: "total > $1", Args: query.Args{10}, GroupBy: []string{"customerID"}, Having: "count(*) > $1", HavingArgs: query.Args{2}},
+			"SELECT COUNT(*) FROM (SELECT 1 FROM public.count_orders  WHERE total > $1 GROUP BY customer_id HAVING count(*) > $2) AS grouped",
+			[]interface{}{10, 2},
+			false,
+		},
+		{"distinct", "DISTINCT count_orders.total", nil, "SELECT COUNT(DISTINCT count_orders.total) FROM public.count_orders ", nil, false},
+		{"grouped distinct", "DISTINCT count_orders.total", &query.QueryFilter{GroupBy: []string{"customer_id"}}, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := countStatement(tblSchema, "postgres", tt.expr, tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("countStatement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if sql != tt.sql {
+				t.Errorf("countStatement() = %q, want %q", sql, tt.sql)
+			}
+
+			if len(args) != 0 || len(tt.args) != 0 {
+				if !reflect.DeepEqual(args, tt.args) {
+					t.Errorf("countStatement() args = %v, want %v", args, tt.args)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+	"github.com/abiiranathan/gosqlorm/pkg/schema"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// Batch queues inserts, updates, deletes and raw statements that are sent
+// to the database in a single round trip with Execute.
+//
+// Create it with ORM.Batch(). Errors building a statement e.g an invalid model
+// are returned by Execute before anything is sent.
+type Batch struct {
+	orm        *orm
+	statements []batchStatement
+	err        error
+}
+
+// A statement queued in a Batch
+type batchStatement struct {
+	sql  string
+	args []interface{}
+
+	// Model the RETURNING row is scanned into, nil for statements without rows
+	result interface{}
+}
+
+// Result of a statement in a Batch
+type BatchResult struct {
+	// Number of rows inserted, updated or deleted by the statement
+	RowsAffected int64
+
+	// Error of the statement. After a failed statement, the following
+	// statements of the batch are not run and also return an error.
+	Err error
+}
+
+// Returns a new batch that runs on the connection pool or transaction of o
+func (o *orm) Batch() *Batch {
+	return &Batch{orm: o}
+}
+
+// Queue a statement. If result is not nil, the row returned by the statement is scanned into it.
+func (b *Batch) queue(sql string, args []interface{}, result interface{}) {
+	b.statements = append(b.statements, batchStatement{sql: sql, args: args, result: result})
+}
+
+// Sets the error of the batch if it has no error yet
+func (b *Batch) fail(err error) *Batch {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Queue the insert of v. The inserted row is scanned back into v
+// unless the batch was created from a NoReturning view.
+// returning lists the columns scanned back into v and defaults to all columns
+func (b *Batch) Create(v interface{}, returning ...string) *Batch {
+	if !schema.IsStructPointer(v) {
+		return b.fail(errors.New("model v must be a pointer to a struct"))
+	}
+
+	tblSchema, err := b.orm.tableSchema(v)
+	if err != nil {
+		return b.fail(err)
+	}
+
+	if b.orm.noReturning {
+		sql, values := tblSchema.InsertSchemaNoReturning(v, b.orm.config.Driver.String(), false)
+		b.queue(sql, values, nil)
+		return b
+	}
+
+	sql, values := tblSchema.InsertSchema(v, b.orm.config.Driver.String(), returning...)
+
+	b.queue(sql, values, v)
+	return b
+}
+
+// Queue the update of v based on conditions. The updated row is scanned back into v
+// unless the batch was created from a NoReturning view.
+func (b *Batch) Update(v interface{}, conditions *query.QueryFilter, returning ...string) *Batch {
+	if !schema.IsStructPointer(v) {
+		return b.fail(errors.New("model v must be a pointer to a struct"))
+	}
+
+	tblSchema, err := b.orm.tableSchema(v)
+	if err != nil {
+		return b.fail(err)
+	}
+
+	if b.orm.noReturning {
+		sql, values, err := tblSchema.UpdateWhereSchemaNoReturning(v, conditions, b.orm.config.Driver.String())
+		if err != nil {
+			return b.fail(err)
+		}
+
+		b.queue(sql, values, nil)
+		return b
+	}
+
+	sql, values, err := tblSchema.UpdateWhereSchema(v, conditions, b.orm.config.Driver.String(), returning...)
+	if err != nil {
+		return b.fail(err)
+	}
+
+	b.queue(sql, values, v)
+	return b
+}
+
+// Queue the delete of the rows of model v matching conditions
+func (b *Batch) Delete(v interface{}, conditions *query.QueryFilter) *Batch {
+	if err := conditions.Validate(); err != nil {
+		return b.fail(err)
+	}
+
+	tblSchema, err := b.orm.tableSchema(v)
+	if err != nil {
+		return b.fail(err)
+	}
+
+	sql := tblSchema.DeleteSchema(b.orm.config.Driver.String())
+
+	q := &query.Query{Driver: b.orm.config.Driver.String(), Query: sql, Filter: conditions}
+	q.AddQueryFilters()
+	b.queue(q.Query, q.Args, nil)
+	return b
+}
+
+// Queue a raw sql statement with args.
+// Like filters, sql is written with $n placeholders that are rebound to the dialect of the ORM.
+func (b *Batch) Exec(sql string, args ...interface{}) *Batch {
+	if d, err := query.DialectFor(b.orm.config.Driver.String()); err == nil {
+		sql, args = query.Rebind(d, sql, args)
+	}
+
+	b.queue(sql, args, nil)
+	return b
+}
+
+// Returns the number of queued statements
+func (b *Batch) Len() int {
+	return len(b.statements)
+}
+
+// Sends the queued statements in one round trip and returns their results in queue order.
+// The returned error is the first error building a statement or the error closing the batch.
+func (b *Batch) Execute(ctx context.Context) ([]BatchResult, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if b.Len() == 0 {
+		return []BatchResult{}, nil
+	}
+
+	var conn interface {
+		SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	}
+
+	if b.orm.tx != nil {
+		conn = b.orm.tx
+	} else {
+		pool, err := b.orm.db()
+		if err != nil {
+			return nil, err
+		}
+		conn = pool
+	}
+
+	// The statements are logged when they are sent
+	batch := &pgx.Batch{}
+	for _, statement := range b.statements {
+		fmt.Fprintf(b.orm.logger(), "[batch] %s %v\n\n", statement.sql, statement.args)
+		batch.Queue(statement.sql, statement.args...)
+	}
+	br := conn.SendBatch(ctx, batch)
+
+	results := make([]BatchResult, len(b.statements))
+	for i, statement := range b.statements {
+		result := statement.result
+		if result == nil {
+			tag, err := br.Exec()
+			results[i] = BatchResult{RowsAffected: tag.RowsAffected(), Err: err}
+			continue
+		}
+
+		rows, err := br.Query()
+		if err == nil {
+			err = pgxscan.ScanOne(result, rows)
+		}
+
+		// No row is returned if the update matched no rows, like query.Create
+		if errors.Is(err, pgx.ErrNoRows) {
+			results[i] = BatchResult{}
+			continue
+		}
+
+		results[i] = BatchResult{Err: err}
+		if err == nil {
+			results[i].RowsAffected = 1
+		}
+	}
+
+	return results, br.Close()
+}
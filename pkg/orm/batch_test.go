@@ -0,0 +1,41 @@
+package orm
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBatchExec(t *testing.T) {
+	tests := []struct {
+		driver DriverName
+		sql    string
+		args   []interface{}
+	}{
+		{POSTGRES, "UPDATE users SET name = $2 WHERE id = $1", []interface{}{1, "ann"}},
+		{MYSQL, "UPDATE users SET name = ? WHERE id = ?", []interface{}{"ann", 1}},
+		{SQLITE, "UPDATE users SET name = ?2 WHERE id = ?1", []interface{}{1, "ann"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.driver), func(t *testing.T) {
+			log := bytes.Buffer{}
+			o := &orm{config: &Config{Driver: tt.driver, LoggerOutput: &log}}
+
+			b := o.Batch().Exec("UPDATE users SET name = $2 WHERE id = $1", 1, "ann")
+			if b.Len() != 1 {
+				t.Fatalf("Len() = %d, want 1", b.Len())
+			}
+
+			statement := b.statements[0]
+			if statement.sql != tt.sql || !reflect.DeepEqual(statement.args, tt.args) {
+				t.Errorf("queued %q %v, want %q %v", statement.sql, statement.args, tt.sql, tt.args)
+			}
+
+			// Statements are logged when the batch is sent
+			if log.Len() != 0 {
+				t.Errorf("queueing logged %q", log.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package orm
+
+import (
+	"context"
+	"net/http"
+)
+
+// Key of the ORM in a context
+type ormContextKey struct{}
+
+// Returns a copy of ctx that carries db.
+// Use FromContext to retrieve it e.g in an http handler.
+func NewContext(ctx context.Context, db ORM) context.Context {
+	return context.WithValue(ctx, ormContextKey{}, db)
+}
+
+// Returns the ORM stored in ctx by NewContext.
+// The returned ORM runs its queries with ctx, so they are canceled with ctx.
+func FromContext(ctx context.Context) (ORM, bool) {
+	db, ok := ctx.Value(ormContextKey{}).(ORM)
+	if !ok || db == nil {
+		return nil, false
+	}
+	return db.WithContext(ctx), true
+}
+
+// Returns an http middleware that stores db in the context of each request.
+// Handlers retrieve it with FromContext(r.Context()), so their queries
+// are canceled when the client disconnects.
+func Middleware(db ORM) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), db)))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package orm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+	"github.com/abiiranathan/gosqlorm/pkg/schema"
+)
+
+// Updates the jsonb column of the rows matching filter with column = column || patch.
+//
+// The keys in patch are added to the json object in the column or replace the existing
+// keys with the same name, the other keys are kept. A null column is set to patch.
+// Nested objects are replaced as a whole, to change a nested key include its parent object.
+func (o *orm) UpdateJSON(model interface{}, column string, patch map[string]interface{}, filter *query.QueryFilter) (int64, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, err
+	}
+
+	tblSchema, err := o.tableSchema(model)
+	if err != nil {
+		return 0, err
+	}
+
+	field := tblSchema.FieldByColumn(schema.SnakeCase(column))
+	if field == nil {
+		return 0, fmt.Errorf("table %s has no column %s", tblSchema.TableName, column)
+	}
+
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return 0, fmt.Errorf("encoding json patch: %w", err)
+	}
+
+	name := schema.SnakeCase(field.Name)
+	sql := fmt.Sprintf("UPDATE %s SET %s = COALESCE(%s, '{}'::jsonb) || $1::jsonb",
+		tblSchema.QualifiedName(), name, name)
+
+	q := o.newQuery(sql, nil, filter, string(data))
+	q.Label = queryLabel(model, "UpdateJSON")
+	if err := q.Exec(); err != nil {
+		return 0, err
+	}
+
+	return q.RowsAffected, nil
+}
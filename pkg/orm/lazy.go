@@ -0,0 +1,99 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// Returned by queries on an ORM with Config.LazyConnect that was closed before it connected
+var errClosed = errors.New("orm is closed")
+
+// Connection pool created on the first query when Config.LazyConnect is set.
+// Shared by all views of the ORM.
+type lazyPool struct {
+	once sync.Once
+	pool *pgxpool.Pool
+	err  error
+}
+
+// Returns the primary connection pool.
+// With Config.LazyConnect, the primary and replica pools are created on the first call.
+func (o *orm) db() (*pgxpool.Pool, error) {
+	if o.lazy == nil {
+		return o.pool, nil
+	}
+
+	o.lazy.once.Do(func() {
+		o.lazy.pool, o.lazy.err = connect(o.config, o.replicas)
+	})
+	return o.lazy.pool, o.lazy.err
+}
+
+// Connects to the primary and replicas of config.
+// The replica pools are added to replicas.
+func connect(config *Config, replicas *replicaSet) (*pgxpool.Pool, error) {
+	pool, err := newDB(config.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, uri := range config.ReplicaURIs {
+		replica, err := newDB(uri)
+		if err != nil {
+			pool.Close()
+			replicas.Close()
+			return nil, fmt.Errorf("connecting to replica: %w", err)
+		}
+		replicas.pools = append(replicas.pools, replica)
+	}
+
+	return pool, nil
+}
+
+// Checks that the database can be reached.
+// With Config.LazyConnect, it connects if o is not connected yet.
+func (o *orm) Ping(ctx context.Context) error {
+	pool, err := o.db()
+	if err != nil {
+		return err
+	}
+	return pool.Ping(ctx)
+}
+
+// Returns the connection pool of the primary, connecting first with Config.LazyConnect.
+// Queries on the pool bypass the transaction o may be bound to.
+func (o *orm) Pool() *pgxpool.Pool {
+	pool, _ := o.db()
+	return pool
+}
+
+// Conn that fails all queries with err e.g when the lazy connection failed
+type errConn struct {
+	err error
+}
+
+func (c errConn) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return nil, c.err
+}
+
+func (c errConn) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return nil, c.err
+}
+
+func (c errConn) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return errRow(c)
+}
+
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
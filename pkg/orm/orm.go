@@ -12,10 +12,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/abiiranathan/gosqlorm/pkg/query"
 	"github.com/abiiranathan/gosqlorm/pkg/schema"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -37,6 +41,9 @@ const (
 var (
 	ErrInvalidDriver = errors.New("invalid driver")
 	ErrDSNEmpty      = errors.New("dataSourceName is empty")
+
+	// Returned by FindByID if there is no row with the primary key
+	ErrRecordNotFound = errors.New("record not found")
 )
 
 type Config struct {
@@ -44,6 +51,56 @@ type Config struct {
 	URI            string
 	EnableFKChecks bool
 	LoggerOutput   io.Writer
+
+	// Don't write queries to LoggerOutput.
+	// The queries of a Debug() view are still written.
+	DisableQueryLog bool
+
+	// Generate NOT NULL for all non-pointer, non-slice fields
+	// that are not explicitly tagged with `orm:"null"`
+	InferNotNull bool
+
+	// Use singular table names e.g user instead of users.
+	// A TableName() method on the model still takes precedence.
+	SingularTableNames bool
+
+	// Plural overrides for table names keyed by the singular snake_case name
+	// e.g {"staff": "staff", "cactus": "cacti"}
+	Plurals map[string]string
+
+	// Postgres schema of the tables. Defaults to public.
+	// Models can override it with a SchemaName() method.
+	Schema string
+
+	// Prefix added to all table names e.g app_ for app_users.
+	// It's also added to names returned by TableName() methods.
+	TablePrefix string
+
+	// Create tables without IF NOT EXISTS, so AutoMigrate fails
+	// if a table already exists instead of silently keeping it
+	StrictMigrate bool
+
+	// Create uniqueIndex constraints as named unique indexes
+	// instead of inline UNIQUE constraints of the table
+	UniqueIndexes bool
+
+	// Return an error from AutoMigrate for models without a primary key.
+	// By default only a warning is printed.
+	RequirePrimaryKey bool
+
+	// Connection strings of read replicas. Find, FindAll, FindAfter, Count and
+	// aggregates are routed round-robin to the replicas, all other queries use URI.
+	// Use ORM.Primary() to read from the primary e.g to read your own writes.
+	ReplicaURIs []string
+
+	// Maximum duration of each query whose context has no deadline.
+	// Zero means queries run without a timeout.
+	DefaultQueryTimeout time.Duration
+
+	// Connect on the first query instead of in NewORM
+	// e.g for serverless functions that may not query the database at all.
+	// Connection errors are then returned by the first query or Ping.
+	LazyConnect bool
 }
 
 // GetDriver returns the driver name for the config c
@@ -56,28 +113,198 @@ func (c *Config) GetDSN() string {
 	return c.URI
 }
 
+// Returns the schema generation options for the config c
+func (c *Config) schemaOptions() schema.Options {
+	return schema.Options{
+		InferNotNull:       c.InferNotNull,
+		SingularTableNames: c.SingularTableNames,
+		Plurals:            c.Plurals,
+		Schema:             c.Schema,
+		TablePrefix:        c.TablePrefix,
+		StrictMigrate:      c.StrictMigrate,
+		UniqueIndexes:      c.UniqueIndexes,
+		RequirePrimaryKey:  c.RequirePrimaryKey,
+	}
+}
+
 type ORM interface {
-	// Find all records from the database for model
+	// Find all records from the database for model.
+	// model must be a pointer to a slice of structs e.g *[]*Model or *[]Model
 	FindAll(model interface{}, filter *query.QueryFilter) error
 
 	// Find a single record from the database specified by the filter
 	Find(model interface{}, filter *query.QueryFilter) error
 
-	// Insert a new record v into the database
-	Create(v interface{}) error
+	// Load the related records of relations (fields with a foreignKey tag)
+	// into v, a pointer to a struct or a slice of structs.
+	Preload(v interface{}, relations ...string) error
+
+	// Find the record of model v by its primary key.
+	// Returns ErrRecordNotFound if there is no such record.
+	FindByID(v interface{}, id interface{}) error
+
+	// Find the first record of model v matching filter ordered by primary key.
+	// Returns ErrRecordNotFound if no record matches. filter may be nil
+	First(v interface{}, filter *query.QueryFilter) error
+
+	// Find the last record of model v matching filter ordered by primary key.
+	// Returns ErrRecordNotFound if no record matches. filter may be nil
+	Last(v interface{}, filter *query.QueryFilter) error
+
+	// Insert a new record v into the database.
+	// returning lists the columns scanned back into v and defaults to all columns
+	Create(v interface{}, returning ...string) error
+
+	// Insert v and return the value of its primary key e.g the generated serial id or uuid.
+	// Only the primary key is scanned back into v.
+	CreateReturningID(v interface{}) (interface{}, error)
 
-	// Update model v based on the consitions
-	Update(v interface{}, conditions *query.QueryFilter) error
+	// Insert v unless it conflicts with an existing row e.g on a unique column.
+	// Returns false without an error if the row already exists, v is then left unchanged.
+	CreateIfNotExists(v interface{}, returning ...string) (bool, error)
+
+	// Insert or update the rows of slice, a pointer to a slice of structs, with multi-row
+	// INSERT ... ON CONFLICT (conflictColumns) DO UPDATE SET updateColumns statements.
+	// The resulting rows are scanned back into slice.
+	UpsertMany(slice interface{}, conflictColumns, updateColumns []string) error
+
+	// Update model v based on the consitions.
+	// returning lists the columns scanned back into v and defaults to all columns
+	Update(v interface{}, conditions *query.QueryFilter, returning ...string) error
+
+	// Merge patch into the jsonb column of the rows of model matching filter.
+	// Only the top-level keys in patch are replaced. Returns the number of rows updated.
+	UpdateJSON(model interface{}, column string, patch map[string]interface{}, filter *query.QueryFilter) (int64, error)
 
 	// Delete model v based on conditions
 	Delete(v interface{}, conditions *query.QueryFilter) error
 
+	// Delete the rows of model with the primary keys in ids.
+	// Returns the number of rows deleted.
+	DeleteByIDs(model interface{}, ids []interface{}) (int64, error)
+
+	// Run a raw sql query and scan the result into dest.
+	// If dest is a pointer to a slice, all rows are scanned into it,
+	// otherwise a single row is scanned.
+	Raw(dest interface{}, sql string, args ...interface{}) error
+
+	// Execute an arbitrary sql statement and return the number of rows affected
+	Exec(ctx context.Context, sql string, args ...interface{}) (int64, error)
+
+	// Remove all rows of the tables of models with a single TRUNCATE statement
+	// e.g to reset the database between tests.
+	Truncate(opts TruncateOptions, models ...interface{}) error
+
+	// Create the postgres extension name e.g pgcrypto or uuid-ossp if it's not installed
+	EnsureExtension(ctx context.Context, name string) error
+
+	// Refresh the materialized view name e.g reports.monthly_sales.
+	// concurrently refreshes without locking out reads and requires a unique index on the view.
+	RefreshMaterializedView(ctx context.Context, name string, concurrently bool) error
+
+	// Run a raw sql query and return the rows as maps keyed by column name.
+	// Useful for queries whose result has no struct e.g admin tools and exports.
+	QueryMaps(ctx context.Context, sql string, args ...interface{}) ([]map[string]interface{}, error)
+
+	// Returns the sum of column for rows of model matching filter. filter may be nil
+	Sum(model interface{}, column string, filter *query.QueryFilter) (float64, error)
+
+	// Returns the average of column for rows of model matching filter. filter may be nil
+	Avg(model interface{}, column string, filter *query.QueryFilter) (float64, error)
+
+	// Scan the minimum value of column for rows of model matching filter into dest
+	// e.g a *time.Time for a timestamp column. filter may be nil
+	Min(model interface{}, column string, dest interface{}, filter *query.QueryFilter) error
+
+	// Scan the maximum value of column for rows of model matching filter into dest. filter may be nil
+	Max(model interface{}, column string, dest interface{}, filter *query.QueryFilter) error
+
+	// Find the next page of at most limit rows after cursor, ordered by column and the primary key.
+	// Returns the cursor for the next page or nil if there are no more rows.
+	FindAfter(v interface{}, filter *query.QueryFilter, column string, after *Cursor, limit int) (*Cursor, error)
+
+	// Returns the number of rows of model matching filter. filter may be nil
+	Count(model interface{}, filter *query.QueryFilter) (int64, error)
+
+	// Returns the number of distinct values of column for rows of model matching filter
+	CountDistinct(model interface{}, column string, filter *query.QueryFilter) (int64, error)
+
+	// Find page (starting at 1) of pageSize rows matching filter into v
+	// and return the total number of rows matching filter.
+	Paginate(v interface{}, filter *query.QueryFilter, page, pageSize int) (int64, error)
+
+	// Same as Paginate but the page and the total are read in a single query with COUNT(*) OVER()
+	PaginateWindow(v interface{}, filter *query.QueryFilter, page, pageSize int) (int64, error)
+
+	// Find the rows matching filter in batches of batchSize rows and call fn with each batch.
+	// The batches have the type of v, a pointer to a slice of structs.
+	FindInBatches(v interface{}, batchSize int, filter *query.QueryFilter, fn func(batch interface{}) error) error
+
 	// Create all tables, constraints, relations for all models.
 	// This is not a proper migration tool.
 	//
 	// TODO: Add proper migration magic for modifying schema
 	AutoMigrate(models ...interface{}) error
 
+	// Same as AutoMigrate but the statements run with ctx, so the migration can be cancelled
+	AutoMigrateContext(ctx context.Context, models ...interface{}) error
+
+	// Same as AutoMigrateContext but returns the SQL and result of each statement that ran
+	AutoMigrateReport(ctx context.Context, models ...interface{}) ([]schema.MigrationResult, error)
+
+	// Register models once so they can be migrated with Migrate
+	RegisterModels(models ...interface{})
+
+	// Migrate all models registered with RegisterModels in dependency order
+	Migrate() error
+
+	// Returns the DDL statements needed to create the missing tables, columns,
+	// indexes and foreign keys of models without executing them.
+	MigrationPlan(models ...interface{}) ([]string, error)
+
+	// Write the DDL for all models to w in foreign key dependency order
+	// without executing it.
+	DumpSchema(w io.Writer, models ...interface{}) error
+
+	// Returns the source of Go models with orm tags for the tables in schemaName
+	// e.g to adopt the ORM on an existing database.
+	GenerateModels(ctx context.Context, schemaName string) (string, error)
+
+	// Returns a view of the ORM that runs all queries with ctx.
+	// The view shares the connection pool and can be used concurrently with the ORM.
+	WithContext(ctx context.Context) ORM
+
+	// Start a transaction with the isolation level and access mode of opts.
+	// Without opts, the default isolation level of the database is used.
+	Begin(ctx context.Context, opts ...pgx.TxOptions) (Tx, error)
+
+	// Run fn in a transaction that is committed if fn returns nil
+	// and rolled back otherwise.
+	Transaction(ctx context.Context, fn func(Tx) error, opts ...pgx.TxOptions) error
+
+	// Run fn in a transaction, retrying it up to maxRetries times
+	// on serialization failures and deadlocks.
+	TransactionWithRetry(ctx context.Context, maxRetries int, fn func(Tx) error, opts ...pgx.TxOptions) error
+
+	// Returns a batch that sends the queued statements in a single round trip
+	Batch() *Batch
+
+	// Returns a view of the ORM that runs reads on the primary instead of the replicas
+	Primary() ORM
+
+	// Returns a view of the ORM whose Create and Update statements have no RETURNING clause
+	NoReturning() ORM
+
+	// Returns a view of the ORM that logs its queries even if DisableQueryLog is set
+	Debug() ORM
+
+	// Checks that the database can be reached, connecting first with Config.LazyConnect
+	Ping(ctx context.Context) error
+
+	// Returns the pgx connection pool of the primary for queries the ORM doesn't cover.
+	// Returns nil if the lazy connection of Config.LazyConnect fails, use Ping to get the error.
+	Pool() *pgxpool.Pool
+
 	// Closes the connection pool
 	Close()
 }
@@ -85,7 +312,31 @@ type ORM interface {
 // Concrete implementation for ORM interface
 type orm struct {
 	config *Config
-	Pool   *pgxpool.Pool
+	pool   *pgxpool.Pool
+
+	// Context of the queries. If nil, context.Background() is used
+	ctx context.Context
+
+	// Transaction the queries run in. If nil, queries run on the pool
+	tx pgx.Tx
+
+	// Models registered with RegisterModels. Shared by all views of the ORM
+	registry *modelRegistry
+
+	// Read replica pools. Shared by all views of the ORM
+	replicas *replicaSet
+
+	// Run reads on the primary pool even if there are replicas
+	primary bool
+
+	// Run Create and Update without a RETURNING clause
+	noReturning bool
+
+	// Log queries even if the query log is disabled
+	debug bool
+
+	// Pool created on first use with Config.LazyConnect. Shared by all views of the ORM
+	lazy *lazyPool
 
 	migrationErr error
 }
@@ -111,24 +362,36 @@ func NewORM(config *Config) (ORM, error) {
 		config.LoggerOutput = os.Stdout
 	}
 
-	pool, err := newDB(config)
+	replicas := &replicaSet{}
+	if config.LazyConnect {
+		return &orm{
+			config:   config,
+			registry: &modelRegistry{},
+			replicas: replicas,
+			lazy:     &lazyPool{},
+		}, nil
+	}
+
+	pool, err := connect(config, replicas)
 	if err != nil {
 		return nil, err
 	}
 
 	return &orm{
-		config: config,
-		Pool:   pool,
+		config:   config,
+		pool:     pool,
+		registry: &modelRegistry{},
+		replicas: replicas,
 	}, nil
 }
 
-// connects to postgres database with config.URI
+// connects to postgres database with uri
 // If successful, it returns a pgxpool.Pool
 // that is then attached to the ORM
 //
 // All queries use this connection pool
-func newDB(config *Config) (*pgxpool.Pool, error) {
-	cfg, err := pgxpool.ParseConfig(config.URI)
+func newDB(uri string) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(uri)
 	if err != nil {
 		return nil, err
 	}
@@ -150,34 +413,167 @@ func (o *orm) GetConfig() *Config {
 // Close closes all connections in the pool and rejects future Acquire calls.
 //Blocks until all connections are returned to pool and closed.
 func (o *orm) Close() {
-	o.Pool.Close()
+	// The pool is owned by the ORM, not the transaction
+	if o.tx != nil {
+		return
+	}
+
+	pool := o.pool
+	if o.lazy != nil {
+		// Later queries fail instead of connecting
+		o.lazy.once.Do(func() { o.lazy.err = errClosed })
+		pool = o.lazy.pool
+	}
+
+	if pool != nil {
+		pool.Close()
+	}
+	o.replicas.Close()
 }
 
-func (o *orm) FindAll(v interface{}, filter *query.QueryFilter) error {
-	if !schema.IsPointerToArrayOfStructPointer(v) {
-		return errors.New("v must be a pointer to a slice of structs")
+// Returns a view of o that runs all queries with ctx.
+// Only the context is copied, the connection pool is shared.
+func (o *orm) WithContext(ctx context.Context) ORM {
+	view := *o
+	view.ctx = ctx
+	return &view
+}
+
+// Returns the context of the queries of o
+func (o *orm) getContext() context.Context {
+	if o.ctx == nil {
+		return context.Background()
 	}
+	return o.ctx
+}
 
-	model := schema.NewStructPointer(v)
-	tableName := schema.GetTableName(model)
-	_, qualified, _ := schema.Columns(model, o.config.Driver.String())
+// Returns a new query bound to the connection pool, driver and context of o
+func (o *orm) newQuery(sql string, result interface{}, filter *query.QueryFilter, args ...interface{}) *query.Query {
+	return &query.Query{
+		Driver:  o.config.Driver.String(),
+		Pool:    o.conn(),
+		Query:   sql,
+		Result:  result,
+		Filter:  filter,
+		Args:    args,
+		Context: o.getContext(),
+		Timeout: o.config.DefaultQueryTimeout,
+		Logger:  o.logger(),
+	}
+}
+
+// Returns the table schema of model v parsed with the schema options of o
+func (o *orm) tableSchema(v interface{}) (*schema.TableSchema, error) {
+	return schema.GetTableSchemaWithOptions(v, o.config.Driver.String(), o.config.schemaOptions())
+}
+
+// Returns the table schemas of models parsed with the schema options of o
+func (o *orm) tableSchemas(models []interface{}) ([]*schema.TableSchema, error) {
+	tables := make([]*schema.TableSchema, 0, len(models))
+	for _, model := range models {
+		tblSchema, err := o.tableSchema(model)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, tblSchema)
+	}
+	return tables, nil
+}
+
+// Returns the writer of the query log, io.Discard if queries are not logged
+func (o *orm) logger() io.Writer {
+	if o.config.DisableQueryLog && !o.debug {
+		return io.Discard
+	}
+	return o.config.LoggerOutput
+}
+
+// Returns a view of o that writes its queries and their args to LoggerOutput
+// even if DisableQueryLog is set. Chain it to debug a single call
+// e.g db.Debug().Find(&user, filter).
+func (o *orm) Debug() ORM {
+	view := *o
+	view.debug = true
+	return &view
+}
+
+// Returns the label of the operation op on model for the query log e.g User.Create.
+// If model is nil, the label is op.
+func queryLabel(model interface{}, op string) string {
+	t := reflect.TypeOf(model)
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Name() == "" {
+		return op
+	}
+	return t.Name() + "." + op
+}
+
+// Returns the SELECT statement for model with a trailing empty space.
+//
+// By default the table qualified columns of model are selected from its table.
+// If filter.From is set, it replaces the table and the unqualified columns of model are
+// selected, so model can be a projection struct (DTO) that is not a table model.
+// filter.Select replaces the selected columns and filter.Joins are added after the table.
+// Returns an error if the schema of model can't be parsed.
+func (o *orm) selectQuery(model interface{}, filter *query.QueryFilter) (string, error) {
+	tblSchema, err := o.tableSchema(model)
+	if err != nil {
+		return "", err
+	}
+
+	tableName := tblSchema.QualifiedName()
+	columns, qualified := tblSchema.Columns()
+
+	selector := strings.Join(qualified, ", ")
+	if filter != nil && filter.From != "" {
+		tableName = filter.From
+
+		unqualified := make([]string, len(columns))
+		for i, column := range columns {
+			unqualified[i] = schema.SnakeCase(column)
+		}
+		selector = strings.Join(unqualified, ", ")
+	}
+
+	if filter != nil && len(filter.Select) > 0 {
+		selector = strings.Join(filter.Select, ", ")
+	}
 
 	buff := bytes.Buffer{}
-	selector := strings.Trim(strings.Join(qualified, ", "), ", ")
-	buff.WriteString(fmt.Sprintf("SELECT %s FROM %s ", selector, tableName))
+	buff.WriteString(fmt.Sprintf("SELECT %s FROM %s%s ", selector, tableName, filter.JoinClause()))
+	return buff.String(), nil
+}
 
-	// Instantiate a new query object
-	q := &query.Query{
-		Driver: o.config.Driver.String(),
-		Pool:   o.Pool,
-		Query:  buff.String(),
-		Result: v,
-		Filter: filter,
+// Find all rows matching filter into v.
+// v must be a pointer to a slice of structs or struct pointers e.g *[]*Model or *[]Model
+func (o *orm) FindAll(v interface{}, filter *query.QueryFilter) error {
+	if err := checkSlice(v); err != nil {
+		return err
 	}
 
+	model := schema.NewStructPointer(v)
+	selectQuery, err := o.selectQuery(model, filter)
+	if err != nil {
+		return err
+	}
+
+	// Instantiate a new query object
+	q := o.newReadQuery(selectQuery, v, filter)
+	q.Label = queryLabel(model, "FindAll")
 	return q.ScanAll()
 }
 
+// Returns an error naming the expected types if v is not a pointer to a slice of structs
+func checkSlice(v interface{}) error {
+	if !schema.IsPointerToSliceOfStructs(v) {
+		return fmt.Errorf("v must be a pointer to a slice of structs e.g *[]*Model or *[]Model, got %T", v)
+	}
+	return nil
+}
+
 // Find a single row in the table
 // v should be a pointer to a struct
 func (o *orm) Find(v interface{}, filter *query.QueryFilter) error {
@@ -190,49 +586,230 @@ func (o *orm) Find(v interface{}, filter *query.QueryFilter) error {
 	}
 
 	model := schema.GetType(v)
-	tableName := schema.GetTableName(model)
-	_, qualified, _ := schema.Columns(model, o.config.Driver.String())
-
-	buff := bytes.Buffer{}
-	selector := strings.Trim(strings.Join(qualified, ", "), ", ")
-	buff.WriteString(fmt.Sprintf("SELECT %s FROM %s ", selector, tableName))
+	selectQuery, err := o.selectQuery(model, filter)
+	if err != nil {
+		return err
+	}
 
 	// Instantiate a new query object
-	q := &query.Query{
-		Driver: o.config.Driver.String(),
-		Pool:   o.Pool,
-		Query:  buff.String(),
-		Result: v,
-		Filter: filter,
+	q := o.newReadQuery(selectQuery, v, filter)
+	q.Label = queryLabel(model, "Find")
+	return q.ScanOne()
+}
+
+// Finds the row of v by its primary key id.
+// The primary key column is read from the schema e.g uuid for Token.UUID.
+// Returns ErrRecordNotFound if there is no row with the primary key.
+func (o *orm) FindByID(v interface{}, id interface{}) error {
+	if !schema.IsStructPointer(v) {
+		return errors.New("model v must be a pointer to a struct")
 	}
 
-	return q.ScanOne()
+	tblSchema, err := o.tableSchema(v)
+	if err != nil {
+		return err
+	}
+
+	pk, err := primaryKey(tblSchema)
+	if err != nil {
+		return err
+	}
+
+	filter := &query.QueryFilter{
+		Where: fmt.Sprintf("%s.%s = $1", tblSchema.ColumnQualifier(), schema.SnakeCase(pk.Name)),
+		Args:  query.Args{id},
+	}
+
+	err = o.Find(v, filter)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrRecordNotFound
+	}
+
+	return err
+}
+
+// Finds the row of v matching filter with the smallest primary key.
+// The OrderBy and Limit of filter are replaced.
+func (o *orm) First(v interface{}, filter *query.QueryFilter) error {
+	return o.findOrdered(v, filter, "ASC")
+}
+
+// Finds the row of v matching filter with the largest primary key.
+// The OrderBy and Limit of filter are replaced.
+func (o *orm) Last(v interface{}, filter *query.QueryFilter) error {
+	return o.findOrdered(v, filter, "DESC")
 }
 
-// Insert a row into the table
-func (o *orm) Create(v interface{}) error {
+// Finds the first row of v matching filter ordered by the primary key columns in direction
+func (o *orm) findOrdered(v interface{}, filter *query.QueryFilter, direction string) error {
 	if !schema.IsStructPointer(v) {
 		return errors.New("model v must be a pointer to a struct")
 	}
 
-	insertQuery, values, err := schema.InsertSchema(v, o.config.Driver.String())
+	tblSchema, err := o.tableSchema(v)
 	if err != nil {
 		return err
 	}
 
-	q := &query.Query{
-		Driver: o.config.Driver.String(),
-		Pool:   o.Pool,
-		Query:  insertQuery,
-		Result: v,
-		Args:   values,
+	if len(tblSchema.PrimaryKeyField()) == 0 {
+		return fmt.Errorf("table %s has no primary key", tblSchema.TableName)
+	}
+
+	ordered := filter.Clone()
+	ordered.OrderBy = primaryKeyOrder(tblSchema, direction)
+	ordered.Limit = 1
+
+	if ordered.Where != "" {
+		if err := ordered.Validate(); err != nil {
+			return err
+		}
+	}
+
+	model := schema.GetType(v)
+	selectQuery, err := o.selectQuery(model, ordered)
+	if err != nil {
+		return err
+	}
+
+	q := o.newReadQuery(selectQuery, v, ordered)
+	q.Label = queryLabel(model, "First")
+	if direction == "DESC" {
+		q.Label = queryLabel(model, "Last")
+	}
+
+	err = q.ScanOne()
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrRecordNotFound
+	}
+
+	return err
+}
+
+// Returns the ORDER BY clause of the primary key columns of the table in direction
+// e.g users.id ASC. Composite keys are ordered by all their columns.
+// Returns an empty string if the table has no primary key.
+func primaryKeyOrder(tblSchema *schema.TableSchema, direction string) string {
+	pkFields := tblSchema.PrimaryKeyField()
+	order := make([]string, len(pkFields))
+	for i, pk := range pkFields {
+		order[i] = fmt.Sprintf("%s.%s %s", tblSchema.ColumnQualifier(), schema.SnakeCase(pk.Name), direction)
+	}
+	return strings.Join(order, ", ")
+}
+
+// Insert a row into the table.
+//
+// The inserted row is scanned back into v. To only fetch some columns
+// e.g the generated id, pass them in returning.
+func (o *orm) Create(v interface{}, returning ...string) error {
+	if !schema.IsStructPointer(v) {
+		return errors.New("model v must be a pointer to a struct")
 	}
 
+	tblSchema, err := o.tableSchema(v)
+	if err != nil {
+		return err
+	}
+
+	if o.noReturning {
+		insertQuery, values := tblSchema.InsertSchemaNoReturning(v, o.config.Driver.String(), false)
+		_, err = o.execNoReturning(insertQuery, queryLabel(v, "Create"), values)
+		return err
+	}
+
+	insertQuery, values := tblSchema.InsertSchema(v, o.config.Driver.String(), returning...)
+
+	q := o.newQuery(insertQuery, v, nil, values...)
+	q.Label = queryLabel(v, "Create")
 	return q.Create()
 }
 
-// Updates model v based on specified conditions
-func (o *orm) Update(v interface{}, conditions *query.QueryFilter) error {
+// Runs the INSERT or UPDATE statement sql of a NoReturning view with Exec
+// and returns the number of rows affected.
+func (o *orm) execNoReturning(sql, label string, values []interface{}) (int64, error) {
+	q := o.newQuery(sql, nil, nil, values...)
+	q.Label = label
+	if err := q.Exec(); err != nil {
+		return 0, err
+	}
+	return q.RowsAffected, nil
+}
+
+// Returns a view of o whose Create, CreateIfNotExists and Update statements have no
+// RETURNING clause and run with Exec, so nothing is scanned back into the model.
+// Use it for statements that can't return rows e.g inserting into a view.
+func (o *orm) NoReturning() ORM {
+	view := *o
+	view.noReturning = true
+	return &view
+}
+
+// Insert a row into the table and return its primary key.
+//
+// The value has the type of the primary key field e.g int or uuid.UUID.
+// Tables with a composite primary key are not supported.
+func (o *orm) CreateReturningID(v interface{}) (interface{}, error) {
+	if !schema.IsStructPointer(v) {
+		return nil, errors.New("model v must be a pointer to a struct")
+	}
+
+	tblSchema, err := o.tableSchema(v)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := primaryKey(tblSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	// The id is read from the RETURNING clause
+	returning := *o
+	returning.noReturning = false
+	if err := returning.Create(v, pk.Name); err != nil {
+		return nil, err
+	}
+
+	return reflect.ValueOf(v).Elem().FieldByName(pk.Name).Interface(), nil
+}
+
+// Insert a row into the table with ON CONFLICT DO NOTHING.
+//
+// created is false if the row conflicts with an existing row and no row
+// is returned by the RETURNING clause.
+func (o *orm) CreateIfNotExists(v interface{}, returning ...string) (bool, error) {
+	if !schema.IsStructPointer(v) {
+		return false, errors.New("model v must be a pointer to a struct")
+	}
+
+	tblSchema, err := o.tableSchema(v)
+	if err != nil {
+		return false, err
+	}
+
+	if o.noReturning {
+		insertQuery, values := tblSchema.InsertSchemaNoReturning(v, o.config.Driver.String(), true)
+		inserted, err := o.execNoReturning(insertQuery, queryLabel(v, "CreateIfNotExists"), values)
+		return inserted > 0, err
+	}
+
+	insertQuery, values := tblSchema.InsertIfNotExistsSchema(v, o.config.Driver.String(), returning...)
+
+	q := o.newQuery(insertQuery, v, nil, values...)
+	q.Label = queryLabel(v, "CreateIfNotExists")
+	if err := q.Create(); err != nil {
+		return false, err
+	}
+
+	return q.RowsAffected > 0, nil
+}
+
+// Updates model v based on specified conditions.
+//
+// The updated row is scanned back into v. To only fetch some columns
+// of wide tables, pass them in returning.
+// v is left unchanged if no row matches the conditions.
+func (o *orm) Update(v interface{}, conditions *query.QueryFilter, returning ...string) error {
 	if !schema.IsStructPointer(v) {
 		return errors.New("model v must be a pointer to a struct")
 	}
@@ -241,19 +818,28 @@ func (o *orm) Update(v interface{}, conditions *query.QueryFilter) error {
 		return err
 	}
 
-	updateQuery, values, err := schema.UpdateSchema(v, conditions, o.config.Driver.String())
+	tblSchema, err := o.tableSchema(v)
 	if err != nil {
 		return err
 	}
 
-	q := &query.Query{
-		Driver: o.config.Driver.String(),
-		Pool:   o.Pool,
-		Query:  updateQuery,
-		Result: v,
-		Args:   values,
-		Filter: conditions,
+	if o.noReturning {
+		updateQuery, values, err := tblSchema.UpdateWhereSchemaNoReturning(v, conditions, o.config.Driver.String())
+		if err != nil {
+			return err
+		}
+
+		_, err = o.execNoReturning(updateQuery, queryLabel(v, "Update"), values)
+		return err
 	}
+
+	updateQuery, values, err := tblSchema.UpdateWhereSchema(v, conditions, o.config.Driver.String(), returning...)
+	if err != nil {
+		return err
+	}
+
+	q := o.newQuery(updateQuery, v, conditions, values...)
+	q.Label = queryLabel(v, "Update")
 	return q.Create()
 }
 
@@ -267,26 +853,294 @@ func (o *orm) Delete(v interface{}, conditions *query.QueryFilter) error {
 		return err
 	}
 
-	deleteQuery, err := schema.DeleteSchema(v, o.config.Driver.String())
+	tblSchema, err := o.tableSchema(v)
 	if err != nil {
 		return err
 	}
 
-	q := &query.Query{
-		Driver: o.config.Driver.String(),
-		Pool:   o.Pool,
-		Query:  deleteQuery,
-		Result: v,
-		Filter: conditions,
-	}
+	deleteQuery := tblSchema.DeleteSchema(o.config.Driver.String())
 
+	q := o.newQuery(deleteQuery, v, conditions)
+	q.Label = queryLabel(v, "Delete")
 	return q.Exec()
 }
 
+// Runs a raw sql query with args and scans the result into dest.
+//
+// If dest is a pointer to a slice e.g *[]*Model, all rows are scanned with ScanAll.
+// Otherwise dest must be a pointer to a struct or scalar and a single row is scanned.
+func (o *orm) Raw(dest interface{}, sql string, args ...interface{}) error {
+	if !schema.IsPointer(dest) {
+		return errors.New("dest must be a pointer")
+	}
+
+	q := o.newQuery(sql, dest, nil, args...)
+	q.Label = "Raw"
+	if reflect.TypeOf(dest).Elem().Kind() == reflect.Slice {
+		return q.ScanAll()
+	}
+
+	return q.ScanOne()
+}
+
+// Executes an arbitrary sql statement that is not tied to a model
+// e.g CREATE EXTENSION or REFRESH MATERIALIZED VIEW.
+//
+// Returns the number of rows affected by the statement.
+func (o *orm) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	q := o.newQuery(sql, nil, nil, args...)
+	q.Label = "Exec"
+	q.Context = ctx
+
+	if err := q.Exec(); err != nil {
+		return 0, err
+	}
+
+	return q.RowsAffected, nil
+}
+
+// Options of ORM.Truncate
+type TruncateOptions struct {
+	// Reset the sequences of serial columns of the tables
+	RestartIdentity bool
+
+	// Also truncate the tables with foreign keys referencing the tables
+	Cascade bool
+}
+
+// Runs TRUNCATE for the tables of models e.g TRUNCATE public.users, public.tokens RESTART IDENTITY CASCADE.
+// Without Cascade, it fails if a table not in models references one of the tables.
+func (o *orm) Truncate(opts TruncateOptions, models ...interface{}) error {
+	if len(models) == 0 {
+		return errors.New("no models to truncate")
+	}
+
+	tables := make([]string, len(models))
+	for i, model := range models {
+		tblSchema, err := o.tableSchema(model)
+		if err != nil {
+			return err
+		}
+		tables[i] = tblSchema.QualifiedName()
+	}
+
+	sql := "TRUNCATE " + strings.Join(tables, ", ")
+	if opts.RestartIdentity {
+		sql += " RESTART IDENTITY"
+	}
+
+	if opts.Cascade {
+		sql += " CASCADE"
+	}
+
+	_, err := o.Exec(o.getContext(), sql)
+	return err
+}
+
+// Runs CREATE EXTENSION IF NOT EXISTS for the extension name.
+// Returns an error if name is not a valid extension name.
+//
+// AutoMigrate creates the extensions of gen_random_uuid() and uuid_generate_v4() defaults.
+func (o *orm) EnsureExtension(ctx context.Context, name string) error {
+	sql, err := schema.CreateExtensionSQL(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = o.Exec(ctx, sql)
+	return err
+}
+
+// Runs REFRESH MATERIALIZED VIEW for the view name, optionally qualified with its schema.
+// Returns an error if name is not a valid identifier.
+func (o *orm) RefreshMaterializedView(ctx context.Context, name string, concurrently bool) error {
+	if _, err := query.Ident(name); err != nil {
+		return err
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = schema.QuoteIdentifier(part, o.config.Driver.String())
+	}
+
+	sql := "REFRESH MATERIALIZED VIEW "
+	if concurrently {
+		sql += "CONCURRENTLY "
+	}
+
+	_, err := o.Exec(ctx, sql+strings.Join(parts, "."))
+	return err
+}
+
+// Runs a raw sql query with args and returns each row as a map of column name to value.
+//
+// Values are decoded to their Go types by pgx e.g int4 -> int32, text -> string
+// and NULL -> nil. Duplicate column names keep the value of the last column.
+func (o *orm) QueryMaps(ctx context.Context, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows := []map[string]interface{}{}
+	q := o.newQuery(sql, &rows, nil, args...)
+	q.Label = "QueryMaps"
+	q.Context = ctx
+
+	if err := q.ScanMaps(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
 // Create all tables and relations.
 //
 // NB: This is not a migration tool. It's just a helper for creating all
 // tables, their constraints, and relations.
 func (o *orm) AutoMigrate(models ...interface{}) error {
-	return schema.AutoMigrate(o.Pool, o.config.Driver.String(), models...)
+	return o.AutoMigrateContext(o.getContext(), models...)
+}
+
+// Creates all tables and relations with ctx.
+// It stops with the context error if ctx is cancelled.
+func (o *orm) AutoMigrateContext(ctx context.Context, models ...interface{}) error {
+	_, err := o.AutoMigrateReport(ctx, models...)
+	return err
+}
+
+// Creates all tables and relations with ctx and returns the result of each statement.
+// Enum types and foreign keys that already exist are reported as not executed with their error.
+func (o *orm) AutoMigrateReport(ctx context.Context, models ...interface{}) ([]schema.MigrationResult, error) {
+	tables, err := o.tableSchemas(models)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := o.db()
+	if err != nil {
+		return nil, err
+	}
+	return schema.AutoMigrateTables(ctx, pool, o.config.Driver.String(), tables...)
+}
+
+// Returns the statements AutoMigrate would need to reconcile the database with models,
+// so migrations can be reviewed before they are applied.
+func (o *orm) MigrationPlan(models ...interface{}) ([]string, error) {
+	tables, err := o.tableSchemas(models)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := o.db()
+	if err != nil {
+		return nil, err
+	}
+	return schema.MigrationPlanTables(pool, o.config.Driver.String(), tables...)
+}
+
+// Writes the schema of all models to w e.g to commit it to version control.
+func (o *orm) DumpSchema(w io.Writer, models ...interface{}) error {
+	tables, err := o.tableSchemas(models)
+	if err != nil {
+		return err
+	}
+	return schema.DumpTables(w, o.config.Driver.String(), tables...)
+}
+
+// Reverse engineers the tables of schemaName into Go models. It's the inverse of AutoMigrate.
+func (o *orm) GenerateModels(ctx context.Context, schemaName string) (string, error) {
+	pool, err := o.db()
+	if err != nil {
+		return "", err
+	}
+	return schema.GenerateModelsWithOptions(ctx, pool, schemaName, o.config.schemaOptions())
+}
+
+// Deletes the rows of model with the primary keys in ids and returns the number of rows deleted.
+//
+// The ids are sent as a single array parameter: DELETE FROM t WHERE pk = ANY($1).
+func (o *orm) DeleteByIDs(model interface{}, ids []interface{}) (int64, error) {
+	tblSchema, err := o.tableSchema(model)
+	if err != nil {
+		return 0, err
+	}
+
+	pk, err := primaryKey(tblSchema)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	filter := &query.QueryFilter{
+		Where: fmt.Sprintf("%s = ANY($1)", schema.SnakeCase(pk.Name)),
+		Args:  query.Args{typedSlice(ids)},
+	}
+
+	q := o.newQuery(tblSchema.DeleteSchema(o.config.Driver.String()), nil, filter)
+	q.Label = queryLabel(model, "DeleteByIDs")
+	if err := q.Exec(); err != nil {
+		return 0, err
+	}
+
+	return q.RowsAffected, nil
+}
+
+// Returns values as a slice of their type e.g []int64 so it's encoded as a typed array.
+// If the values have different types, values is returned as is.
+func typedSlice(values []interface{}) interface{} {
+	t := reflect.TypeOf(values[0])
+	for _, v := range values {
+		if t == nil || reflect.TypeOf(v) != t {
+			return values
+		}
+	}
+
+	slice := reflect.MakeSlice(reflect.SliceOf(t), 0, len(values))
+	for _, v := range values {
+		slice = reflect.Append(slice, reflect.ValueOf(v))
+	}
+	return slice.Interface()
+}
+
+// Models registered with the ORM
+type modelRegistry struct {
+	mu     sync.Mutex
+	models []interface{}
+
+	// Struct types of the registered models
+	types map[reflect.Type]bool
+}
+
+// Registers models to be migrated by Migrate.
+// Registering a model more than once has no effect, a struct and a pointer
+// or slice of the same struct are the same model.
+func (o *orm) RegisterModels(models ...interface{}) {
+	o.registry.mu.Lock()
+	defer o.registry.mu.Unlock()
+
+	if o.registry.types == nil {
+		o.registry.types = map[reflect.Type]bool{}
+	}
+
+	for _, model := range models {
+		t := reflect.TypeOf(model)
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			t = t.Elem()
+		}
+
+		if o.registry.types[t] {
+			continue
+		}
+
+		o.registry.types[t] = true
+		o.registry.models = append(o.registry.models, model)
+	}
+}
+
+// Creates the tables of all registered models like AutoMigrate.
+func (o *orm) Migrate() error {
+	o.registry.mu.Lock()
+	models := append([]interface{}{}, o.registry.models...)
+	o.registry.mu.Unlock()
+
+	return o.AutoMigrate(models...)
 }
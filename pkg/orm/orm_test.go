@@ -0,0 +1,76 @@
+package orm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+)
+
+type invalidSizeModel struct {
+	ID   int    `orm:"primaryKey;autoIncrement"`
+	Name string `orm:"size:long"`
+}
+
+func TestReadsReturnSchemaErrors(t *testing.T) {
+	db := lazyORM(t)
+	filter := &query.QueryFilter{Where: "id = $1", Args: query.Args{1}}
+
+	tests := []struct {
+		name string
+		read func() error
+	}{
+		{"FindAll", func() error { return db.FindAll(&[]invalidSizeModel{}, nil) }},
+		{"Find", func() error { return db.Find(&invalidSizeModel{}, filter) }},
+		{"First", func() error { return db.First(&invalidSizeModel{}, nil) }},
+		{"Last", func() error { return db.Last(&invalidSizeModel{}, nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.read(); err == nil || !strings.Contains(err.Error(), "invalid size") {
+				t.Errorf("%s() error = %v, want the invalid size error", tt.name, err)
+			}
+		})
+	}
+}
+
+type registeredModel struct {
+	ID int `orm:"primaryKey;autoIncrement"`
+}
+
+func TestRegisterModelsOnce(t *testing.T) {
+	db := lazyORM(t)
+	db.RegisterModels(&registeredModel{}, registeredModel{})
+	db.RegisterModels(&[]*registeredModel{}, &invalidSizeModel{})
+
+	models := db.(*orm).registry.models
+	if len(models) != 2 {
+		t.Errorf("registered %d models %v, want 2", len(models), models)
+	}
+}
+
+type labeledUser struct {
+	ID int
+}
+
+func TestQueryLabel(t *testing.T) {
+	tests := []struct {
+		model interface{}
+		op    string
+		want  string
+	}{
+		{labeledUser{}, "Find", "labeledUser.Find"},
+		{&labeledUser{}, "Create", "labeledUser.Create"},
+		{[]labeledUser{}, "FindAll", "labeledUser.FindAll"},
+		{&[]*labeledUser{}, "CreateMany", "labeledUser.CreateMany"},
+		{struct{ ID int }{}, "Find", "Find"},
+		{nil, "Exec", "Exec"},
+	}
+
+	for _, tt := range tests {
+		if got := queryLabel(tt.model, tt.op); got != tt.want {
+			t.Errorf("queryLabel(%T, %s) = %s, want %s", tt.model, tt.op, got, tt.want)
+		}
+	}
+}
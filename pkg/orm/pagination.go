@@ -0,0 +1,298 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+	"github.com/abiiranathan/gosqlorm/pkg/schema"
+	"github.com/jackc/pgx/v4"
+)
+
+// Cursor is the position of the last row of a keyset (cursor) page.
+// Pass it to FindAfter to fetch the next page.
+type Cursor struct {
+	// Value of the ordering column in the last row
+	Value interface{}
+
+	// Primary key of the last row. Breaks ties between rows with the same Value
+	ID interface{}
+}
+
+// Returns the primary key field of the table or an error
+// if it has no primary key or a composite primary key
+func primaryKey(tblSchema *schema.TableSchema) (*schema.Field, error) {
+	pkFields := tblSchema.PrimaryKeyField()
+	if len(pkFields) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key", tblSchema.TableName)
+	}
+
+	if len(pkFields) > 1 {
+		return nil, fmt.Errorf("table %s has a composite primary key", tblSchema.TableName)
+	}
+
+	return pkFields[0], nil
+}
+
+// Finds the next page of at most limit rows ordered by column and the primary key
+// using keyset pagination: WHERE (column, pk) > ($1, $2) ORDER BY column, pk LIMIT n.
+//
+// v must be a pointer to a slice of structs e.g *[]*Model or *[]Model.
+// Pass a nil cursor for the first page. filter is optional and is combined with the keyset condition.
+// Returns the cursor for the next page or nil if there are no more rows.
+func (o *orm) FindAfter(v interface{}, filter *query.QueryFilter, column string, after *Cursor, limit int) (*Cursor, error) {
+	if err := checkSlice(v); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		return nil, errors.New("limit must be greater than zero")
+	}
+
+	model := schema.NewStructPointer(v)
+	tblSchema, err := o.tableSchema(model)
+	if err != nil {
+		return nil, err
+	}
+
+	pk, err := primaryKey(tblSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	column = schema.SnakeCase(column)
+	orderField := tblSchema.FieldByColumn(column)
+	if orderField == nil {
+		return nil, fmt.Errorf("column %s does not exist in table %s", column, tblSchema.TableName)
+	}
+
+	table := tblSchema.ColumnQualifier()
+	orderColumn := fmt.Sprintf("%s.%s", table, column)
+	pkColumn := fmt.Sprintf("%s.%s", table, schema.SnakeCase(pk.Name))
+
+	keyset := filter.Clone()
+	if orderField == pk {
+		keyset.OrderBy = pkColumn
+		if after != nil {
+			keyset = keyset.And(fmt.Sprintf("%s > $1", pkColumn), after.ID)
+		}
+	} else {
+		keyset.OrderBy = fmt.Sprintf("%s, %s", orderColumn, pkColumn)
+		if after != nil {
+			keyset = keyset.And(fmt.Sprintf("(%s, %s) > ($1, $2)", orderColumn, pkColumn), after.Value, after.ID)
+		}
+	}
+	keyset.Limit = limit
+
+	selectQuery, err := o.selectQuery(model, keyset)
+	if err != nil {
+		return nil, err
+	}
+
+	q := o.newReadQuery(selectQuery, v, keyset)
+	q.Label = queryLabel(model, "FindAfter")
+	if err := q.ScanAll(); err != nil {
+		return nil, err
+	}
+
+	rows := reflect.ValueOf(v).Elem()
+	if rows.Len() < limit {
+		return nil, nil
+	}
+
+	last := reflect.Indirect(rows.Index(rows.Len() - 1))
+	return &Cursor{
+		Value: last.FieldByName(orderField.Name).Interface(),
+		ID:    last.FieldByName(pk.Name).Interface(),
+	}, nil
+}
+
+// Finds page (starting at 1) of pageSize rows matching filter into v
+// and returns the total number of rows matching filter.
+//
+// v must be a pointer to a slice of structs e.g *[]*Model or *[]Model.
+// The count and the page are read in a single read only, repeatable read
+// transaction so they come from the same snapshot. Without filter.OrderBy,
+// the rows are ordered by the primary key.
+func (o *orm) Paginate(v interface{}, filter *query.QueryFilter, page, pageSize int) (int64, error) {
+	if err := checkSlice(v); err != nil {
+		return 0, err
+	}
+
+	if page < 1 || pageSize < 1 {
+		return 0, errors.New("page and pageSize must be greater than zero")
+	}
+
+	model := schema.NewStructPointer(v)
+	tblSchema, err := o.tableSchema(model)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx := o.getContext()
+	tx, err := o.beginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return 0, err
+	}
+
+	// Rollback is a no-op after a successful commit
+	defer tx.Rollback(ctx)
+
+	total, err := tx.Count(model, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.FindAll(v, pageFilter(tblSchema, filter, page, pageSize)); err != nil {
+		return 0, err
+	}
+
+	return total, tx.Commit(ctx)
+}
+
+// Returns a copy of filter limited to page of pageSize rows.
+//
+// Offset pages are only stable if the rows have a total order, so filters without
+// an OrderBy are ordered by the primary key. Filters selecting from another table
+// with From or grouping the rows keep their order.
+func pageFilter(tblSchema *schema.TableSchema, filter *query.QueryFilter, page, pageSize int) *query.QueryFilter {
+	paged := filter.Clone()
+	paged.Limit = pageSize
+	paged.Offset = (page - 1) * pageSize
+
+	if paged.OrderBy == "" && paged.From == "" && len(paged.GroupBy) == 0 {
+		paged.OrderBy = primaryKeyOrder(tblSchema, "ASC")
+	}
+	return paged
+}
+
+// Same as Paginate but the page and the total are read with a single query
+// that selects COUNT(*) OVER() with the model columns.
+//
+// filter.Select and filter.From are not supported, the columns of the model are selected.
+// If the page is past the last row, the total is counted with a second query.
+func (o *orm) PaginateWindow(v interface{}, filter *query.QueryFilter, page, pageSize int) (int64, error) {
+	if err := checkSlice(v); err != nil {
+		return 0, err
+	}
+
+	if page < 1 || pageSize < 1 {
+		return 0, errors.New("page and pageSize must be greater than zero")
+	}
+
+	model := schema.NewStructPointer(v)
+	tblSchema, err := o.tableSchema(model)
+	if err != nil {
+		return 0, err
+	}
+
+	// The total is scanned from the extra column, the other columns into the fields
+	fields := []*schema.Field{}
+	columns := []string{}
+	for _, field := range tblSchema.Fields {
+		if field.IsRelation() {
+			continue
+		}
+
+		fields = append(fields, field)
+		columns = append(columns, fmt.Sprintf("%s.%s", tblSchema.ColumnQualifier(), schema.SnakeCase(field.Name)))
+	}
+	columns = append(columns, "COUNT(*) OVER() AS total_count")
+
+	paged := pageFilter(tblSchema, filter, page, pageSize)
+	sql := fmt.Sprintf("SELECT %s FROM %s%s ", strings.Join(columns, ", "), tblSchema.QualifiedName(), paged.JoinClause())
+
+	rows := reflect.ValueOf(v).Elem()
+	elemType := rows.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	result := reflect.MakeSlice(rows.Type(), 0, pageSize)
+
+	var total int64
+	q := o.newReadQuery(sql, nil, paged)
+	q.Label = queryLabel(v, "PaginateWindow")
+	err = q.ScanRows(func(r pgx.Rows) error {
+		row := reflect.New(structType)
+
+		targets := make([]interface{}, 0, len(fields)+1)
+		for _, field := range fields {
+			targets = append(targets, row.Elem().FieldByName(field.Name).Addr().Interface())
+		}
+		targets = append(targets, &total)
+
+		if err := r.Scan(targets...); err != nil {
+			return err
+		}
+
+		if elemType.Kind() == reflect.Pointer {
+			result = reflect.Append(result, row)
+		} else {
+			result = reflect.Append(result, row.Elem())
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	rows.Set(result)
+
+	// There is no row to read the total from
+	if result.Len() == 0 && page > 1 {
+		return o.Count(model, filter)
+	}
+
+	return total, nil
+}
+
+// Finds the rows matching filter in batches of batchSize rows ordered by primary key
+// and calls fn with each batch until all rows are processed.
+//
+// v is a pointer to a slice of structs e.g *[]*Model that gives the type of the batches,
+// fn receives a new pointer of the same type for each batch. The batches are fetched
+// with keyset pagination, so rows are not skipped or repeated when earlier rows change.
+// Stops and returns the error of fn if it fails.
+func (o *orm) FindInBatches(v interface{}, batchSize int, filter *query.QueryFilter, fn func(batch interface{}) error) error {
+	if err := checkSlice(v); err != nil {
+		return err
+	}
+
+	if batchSize <= 0 {
+		return errors.New("batchSize must be greater than zero")
+	}
+
+	tblSchema, err := o.tableSchema(v)
+	if err != nil {
+		return err
+	}
+
+	pk, err := primaryKey(tblSchema)
+	if err != nil {
+		return err
+	}
+
+	var cursor *Cursor
+	for {
+		batch := reflect.New(reflect.TypeOf(v).Elem())
+		next, err := o.FindAfter(batch.Interface(), filter, pk.Name, cursor, batchSize)
+		if err != nil {
+			return err
+		}
+
+		if batch.Elem().Len() > 0 {
+			if err := fn(batch.Interface()); err != nil {
+				return err
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,56 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+	"github.com/abiiranathan/gosqlorm/pkg/schema"
+)
+
+type pageUser struct {
+	ID   int `orm:"primaryKey;autoIncrement"`
+	Name string
+}
+
+type pageMembership struct {
+	GroupID int `orm:"primaryKey"`
+	UserID  int `orm:"primaryKey"`
+}
+
+func TestPageFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   interface{}
+		filter  *query.QueryFilter
+		orderBy string
+	}{
+		{"nil filter", &pageUser{}, nil, "page_users.id ASC"},
+		{"without order", &pageUser{}, &query.QueryFilter{Where: "name = $1", Args: query.Args{"ann"}}, "page_users.id ASC"},
+		{"with order", &pageUser{}, &query.QueryFilter{OrderBy: "name DESC"}, "name DESC"},
+		{"composite key", &pageMembership{}, nil, "page_memberships.group_id ASC, page_memberships.user_id ASC"},
+		{"grouped", &pageUser{}, &query.QueryFilter{GroupBy: []string{"name"}}, ""},
+		{"from", &pageUser{}, &query.QueryFilter{From: "active_users"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tblSchema, err := schema.GetTableSchema(tt.model, "postgres")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			paged := pageFilter(tblSchema, tt.filter, 3, 10)
+			if paged.OrderBy != tt.orderBy {
+				t.Errorf("OrderBy = %q, want %q", paged.OrderBy, tt.orderBy)
+			}
+
+			if paged.Limit != 10 || paged.Offset != 20 {
+				t.Errorf("Limit, Offset = %d, %d, want 10, 20", paged.Limit, paged.Offset)
+			}
+
+			if tt.filter != nil && tt.filter.Limit != 0 {
+				t.Error("pageFilter modified the filter")
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+	"github.com/abiiranathan/gosqlorm/pkg/schema"
+)
+
+// Loads the related records of relations into v.
+//
+// v must be a pointer to a struct or a pointer to a slice of struct pointers
+// that has already been loaded e.g with FindAll. relations are the names of
+// fields with a foreignKey tag of the form ChildFK->ParentPK:
+//
+//	Tokens  []Token     `orm:"foreignKey:UserID->ID"` // has many
+//	Profile UserProfile `orm:"foreignKey:UserID->ID"` // has one
+//
+// Each relation is loaded with a single query: WHERE child_fk IN (parent keys).
+func (o *orm) Preload(v interface{}, relations ...string) error {
+	parents, err := preloadParents(v)
+	if err != nil {
+		return err
+	}
+
+	if len(parents) == 0 {
+		return nil
+	}
+
+	for _, relation := range relations {
+		if err := o.preload(parents, relation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the struct values of v, a pointer to a struct or a pointer to a slice of structs
+func preloadParents(v interface{}) ([]reflect.Value, error) {
+	if schema.IsStructPointer(v) {
+		return []reflect.Value{reflect.ValueOf(v).Elem()}, nil
+	}
+
+	if !schema.IsPointerToSliceOfStructs(v) {
+		return nil, fmt.Errorf("v must be a pointer to a struct or a pointer to a slice of structs e.g *[]*Model or *[]Model, got %T", v)
+	}
+
+	rows := reflect.ValueOf(v).Elem()
+	parents := make([]reflect.Value, 0, rows.Len())
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		if row.Kind() == reflect.Pointer {
+			if row.IsNil() {
+				continue
+			}
+			row = row.Elem()
+		}
+		parents = append(parents, row)
+	}
+
+	return parents, nil
+}
+
+// Loads relation for all parents with one query
+func (o *orm) preload(parents []reflect.Value, relation string) error {
+	field, ok := parents[0].Type().FieldByName(relation)
+	if !ok {
+		return fmt.Errorf("%s has no field %s", parents[0].Type().Name(), relation)
+	}
+
+	tag := ""
+	for _, t := range strings.Split(field.Tag.Get("orm"), ";") {
+		if k, v, found := strings.Cut(strings.TrimSpace(t), ":"); found && strings.TrimSpace(k) == "foreignKey" {
+			tag = strings.TrimSpace(v)
+		}
+	}
+
+	keys := strings.Split(tag, "->")
+	if len(keys) != 2 {
+		return fmt.Errorf("field %s has no foreignKey tag of the form ChildFK->ParentPK", relation)
+	}
+	childFK, parentPK := strings.TrimSpace(keys[0]), strings.TrimSpace(keys[1])
+
+	// The child type of []Child, []*Child, *Child or Child
+	childType := field.Type
+	hasMany := childType.Kind() == reflect.Slice
+	if hasMany {
+		childType = childType.Elem()
+	}
+
+	childPointer := childType.Kind() == reflect.Pointer
+	if childPointer {
+		childType = childType.Elem()
+	}
+
+	if childType.Kind() != reflect.Struct {
+		return fmt.Errorf("field %s is not a struct or a slice of structs", relation)
+	}
+
+	if _, ok := childType.FieldByName(childFK); !ok {
+		return fmt.Errorf("%s has no field %s", childType.Name(), childFK)
+	}
+
+	// Collect the distinct parent keys
+	parentKeys := []interface{}{}
+	seen := map[string]bool{}
+	for _, parent := range parents {
+		pk := parent.FieldByName(parentPK)
+		if !pk.IsValid() {
+			return fmt.Errorf("%s has no field %s", parent.Type().Name(), parentPK)
+		}
+
+		key := fmt.Sprint(pk.Interface())
+		if !seen[key] {
+			seen[key] = true
+			parentKeys = append(parentKeys, pk.Interface())
+		}
+	}
+
+	// Load the children into a *[]*Child
+	children := reflect.New(reflect.SliceOf(reflect.PointerTo(childType)))
+	if err := o.FindAll(children.Interface(), query.In(childFK, parentKeys...)); err != nil {
+		return err
+	}
+
+	// Group the children by their foreign key
+	byParent := map[string][]reflect.Value{}
+	for i := 0; i < children.Elem().Len(); i++ {
+		child := children.Elem().Index(i)
+		key := fmt.Sprint(child.Elem().FieldByName(childFK).Interface())
+		byParent[key] = append(byParent[key], child)
+	}
+
+	for _, parent := range parents {
+		related := byParent[fmt.Sprint(parent.FieldByName(parentPK).Interface())]
+		target := parent.FieldByName(relation)
+
+		if hasMany {
+			slice := reflect.MakeSlice(field.Type, 0, len(related))
+			for _, child := range related {
+				if childPointer {
+					slice = reflect.Append(slice, child)
+				} else {
+					slice = reflect.Append(slice, child.Elem())
+				}
+			}
+			target.Set(slice)
+		} else if len(related) > 0 {
+			if childPointer {
+				target.Set(related[0])
+			} else {
+				target.Set(related[0].Elem())
+			}
+		}
+	}
+
+	return nil
+}
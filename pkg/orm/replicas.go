@@ -0,0 +1,71 @@
+package orm
+
+import (
+	"sync/atomic"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// Connection pools of the read replicas
+type replicaSet struct {
+	pools []*pgxpool.Pool
+
+	// Number of reads routed to the replicas
+	reads uint32
+}
+
+// Returns the next replica pool in round-robin order or nil if there are no replicas
+func (r *replicaSet) next() *pgxpool.Pool {
+	if r == nil || len(r.pools) == 0 {
+		return nil
+	}
+
+	n := atomic.AddUint32(&r.reads, 1)
+	return r.pools[(n-1)%uint32(len(r.pools))]
+}
+
+// Closes the replica pools
+func (r *replicaSet) Close() {
+	if r == nil {
+		return
+	}
+
+	for _, pool := range r.pools {
+		pool.Close()
+	}
+}
+
+// Returns the connection reads of o run on.
+// Reads in a transaction or on a Primary view use the primary, otherwise a replica if there is one.
+func (o *orm) readConn() query.Conn {
+	if o.tx != nil || o.primary {
+		return o.conn()
+	}
+
+	// The replicas are connected with the primary
+	if _, err := o.db(); err != nil {
+		return errConn{err}
+	}
+
+	if replica := o.replicas.next(); replica != nil {
+		return replica
+	}
+
+	return o.conn()
+}
+
+// Same as newQuery but the query runs on a read replica if there is one
+func (o *orm) newReadQuery(sql string, result interface{}, filter *query.QueryFilter, args ...interface{}) *query.Query {
+	q := o.newQuery(sql, result, filter, args...)
+	q.Pool = o.readConn()
+	return q
+}
+
+// Returns a view of o that runs all reads on the primary.
+// Use it to read rows right after writing them, replicas may lag behind the primary.
+func (o *orm) Primary() ORM {
+	view := *o
+	view.primary = true
+	return &view
+}
@@ -0,0 +1,51 @@
+package orm
+
+import "github.com/abiiranathan/gosqlorm/pkg/query"
+
+// Repository is a type safe wrapper around the ORM for model T.
+// T must be a struct type.
+//
+// e.g users := orm.Repo[models.User](db)
+type Repository[T any] struct {
+	db ORM
+}
+
+// Repo returns a Repository for model T that runs all queries with db
+func Repo[T any](db ORM) *Repository[T] {
+	return &Repository[T]{db: db}
+}
+
+// Find a single record specified by the filter
+func (r *Repository[T]) Find(filter *query.QueryFilter) (*T, error) {
+	v := new(T)
+	if err := r.db.Find(v, filter); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Find all records matching the filter. filter may be nil
+func (r *Repository[T]) FindAll(filter *query.QueryFilter) ([]*T, error) {
+	records := []*T{}
+	if err := r.db.FindAll(&records, filter); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Insert a new record v into the database.
+// returning lists the columns scanned back into v and defaults to all columns
+func (r *Repository[T]) Create(v *T, returning ...string) error {
+	return r.db.Create(v, returning...)
+}
+
+// Update record v based on the conditions in filter.
+// returning lists the columns scanned back into v and defaults to all columns
+func (r *Repository[T]) Update(v *T, filter *query.QueryFilter, returning ...string) error {
+	return r.db.Update(v, filter, returning...)
+}
+
+// Delete record v based on the conditions in filter
+func (r *Repository[T]) Delete(v *T, filter *query.QueryFilter) error {
+	return r.db.Delete(v, filter)
+}
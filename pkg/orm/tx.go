@@ -0,0 +1,160 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// SQLSTATE codes of errors that are resolved by retrying the transaction
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// Delay before the first retry of a transaction. It's doubled on every retry.
+var retryBackoff = 10 * time.Millisecond
+
+// Tx is an ORM whose queries run in a database transaction.
+//
+// Transactions started from a Tx are nested with savepoints.
+type Tx interface {
+	ORM
+
+	// Commits the transaction
+	Commit(ctx context.Context) error
+
+	// Rolls back the transaction. It's a no-op if the transaction was committed
+	Rollback(ctx context.Context) error
+}
+
+// Concrete implementation of Tx.
+// The embedded orm is a view of the ORM bound to the transaction.
+type transaction struct {
+	*orm
+}
+
+func (t *transaction) Commit(ctx context.Context) error {
+	return t.tx.Commit(ctx)
+}
+
+func (t *transaction) Rollback(ctx context.Context) error {
+	return t.tx.Rollback(ctx)
+}
+
+// Returns the connection queries of o run on: the transaction or the connection pool
+func (o *orm) conn() query.Conn {
+	if o.tx != nil {
+		return o.tx
+	}
+
+	pool, err := o.db()
+	if err != nil {
+		return errConn{err}
+	}
+	return pool
+}
+
+// Starts a transaction on the connection pool or a nested transaction (savepoint)
+// if o is bound to a transaction.
+func (o *orm) beginTx(ctx context.Context, opts pgx.TxOptions) (*transaction, error) {
+	var tx pgx.Tx
+	var err error
+
+	if o.tx != nil {
+		tx, err = o.tx.Begin(ctx)
+	} else {
+		pool, poolErr := o.db()
+		if poolErr != nil {
+			return nil, poolErr
+		}
+		tx, err = pool.BeginTx(ctx, opts)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	view := *o
+	view.tx = tx
+	view.ctx = ctx
+	return &transaction{orm: &view}, nil
+}
+
+// Returns the transaction options in opts or the default options
+// that use the default isolation level of the database.
+func txOptions(opts []pgx.TxOptions) pgx.TxOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return pgx.TxOptions{}
+}
+
+// Starts a transaction. The caller must Commit or Rollback the transaction.
+//
+// opts sets the isolation level and access mode
+// e.g pgx.TxOptions{IsoLevel: pgx.Serializable, AccessMode: pgx.ReadOnly}.
+// Options are ignored for nested transactions, which are savepoints of the outer transaction.
+func (o *orm) Begin(ctx context.Context, opts ...pgx.TxOptions) (Tx, error) {
+	return o.beginTx(ctx, txOptions(opts))
+}
+
+// Runs fn in a transaction started with opts.
+//
+// The transaction is committed if fn returns nil, otherwise it's rolled back
+// and the error of fn is returned.
+func (o *orm) Transaction(ctx context.Context, fn func(Tx) error, opts ...pgx.TxOptions) error {
+	tx, err := o.beginTx(ctx, txOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op after a successful commit
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Runs fn in a transaction like Transaction and re-runs it up to maxRetries times
+// if the transaction fails with a serialization failure (40001) or a deadlock (40P01).
+//
+// The delay between attempts starts at 10ms and doubles on every retry.
+// fn must be safe to run more than once.
+// Retries are only useful with pgx.Serializable or pgx.RepeatableRead in opts.
+func (o *orm) TransactionWithRetry(ctx context.Context, maxRetries int, fn func(Tx) error, opts ...pgx.TxOptions) error {
+	backoff := retryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := o.Transaction(ctx, fn, opts...)
+		if err == nil || attempt >= maxRetries || !IsRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// Returns true if err is a serialization failure or a deadlock,
+// meaning that the transaction can succeed if it's retried.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected
+}
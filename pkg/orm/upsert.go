@@ -0,0 +1,124 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/abiiranathan/gosqlorm/pkg/schema"
+	"github.com/jackc/pgx/v4"
+)
+
+// Maximum number of bind parameters in a postgres statement
+const maxParams = 65535
+
+// Inserts the rows of slice or updates the rows that conflict on conflictColumns.
+//
+// slice must be a pointer to a slice of structs e.g *[]*Model or *[]Model.
+// The rows are inserted with multi-row INSERT ... ON CONFLICT statements chunked to
+// respect the parameter limit. The chunks run in a transaction, so all rows are
+// upserted or none. updateColumns are set from the proposed row (EXCLUDED) on conflict.
+// If updateColumns is empty, conflicting rows are skipped.
+// Rows with the same values in conflictColumns return an error,
+// since a statement can't update the same row twice.
+//
+// slice is replaced with the inserted and updated rows. Skipped rows are not returned.
+func (o *orm) UpsertMany(slice interface{}, conflictColumns, updateColumns []string) error {
+	if err := checkSlice(slice); err != nil {
+		return err
+	}
+
+	if len(conflictColumns) == 0 {
+		return errors.New("conflictColumns cannot be empty")
+	}
+
+	tblSchema, err := o.tableSchema(slice)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range append(append([]string{}, conflictColumns...), updateColumns...) {
+		if tblSchema.FieldByColumn(schema.SnakeCase(column)) == nil {
+			return fmt.Errorf("column %s does not exist in table %s", schema.SnakeCase(column), tblSchema.TableName)
+		}
+	}
+
+	rows := reflect.ValueOf(slice).Elem()
+	if rows.Len() == 0 {
+		return nil
+	}
+
+	values := make([]reflect.Value, 0, rows.Len())
+	seen := make(map[string]int, rows.Len())
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		if row.Kind() == reflect.Pointer {
+			if row.IsNil() {
+				return fmt.Errorf("row %d is nil", i)
+			}
+			row = row.Elem()
+		}
+
+		key := conflictKey(tblSchema, row, conflictColumns)
+		if j, ok := seen[key]; ok {
+			return fmt.Errorf("rows %d and %d have the same values for conflict columns %v", j, i, conflictColumns)
+		}
+		seen[key] = i
+
+		values = append(values, row)
+	}
+
+	fields := tblSchema.InsertColumns(values)
+	if len(fields) == 0 {
+		return fmt.Errorf("table %s has no columns to insert", tblSchema.TableName)
+	}
+	chunkSize := maxParams / len(fields)
+
+	ctx := o.getContext()
+	tx, err := o.beginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	// Rollback is a no-op after a successful commit
+	defer tx.Rollback(ctx)
+
+	result := reflect.MakeSlice(rows.Type(), 0, rows.Len())
+	for start := 0; start < len(values); start += chunkSize {
+		end := start + chunkSize
+		if end > len(values) {
+			end = len(values)
+		}
+
+		sql, args := tblSchema.UpsertSchema(values[start:end], fields, o.config.Driver.String(), conflictColumns, updateColumns)
+
+		chunk := reflect.New(rows.Type())
+		q := tx.newQuery(sql, chunk.Interface(), nil, args...)
+		q.Label = queryLabel(slice, "UpsertMany")
+		if err := q.ScanAll(); err != nil {
+			return err
+		}
+		result = reflect.AppendSlice(result, chunk.Elem())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	rows.Set(result)
+	return nil
+}
+
+// Returns the values of the conflictColumns of row as a string key.
+// Pointers are dereferenced so rows are compared by value.
+func conflictKey(tblSchema *schema.TableSchema, row reflect.Value, conflictColumns []string) string {
+	key := make([]interface{}, len(conflictColumns))
+	for i, column := range conflictColumns {
+		field := tblSchema.FieldByColumn(schema.SnakeCase(column))
+		value := reflect.Indirect(row.FieldByName(field.Name))
+		if value.IsValid() {
+			key[i] = value.Interface()
+		}
+	}
+	return fmt.Sprintf("%#v", key)
+}
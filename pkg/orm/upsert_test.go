@@ -0,0 +1,67 @@
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+type upsertProduct struct {
+	ID    int    `orm:"primaryKey;autoIncrement"`
+	SKU   string `orm:"unique"`
+	Store *int
+	Price int
+}
+
+type upsertComputed struct {
+	Total int `orm:"generated:1 + 1"`
+}
+
+// Returns an ORM that never connects, for the errors returned before a query runs
+func lazyORM(t *testing.T) ORM {
+	t.Helper()
+
+	db, err := NewORM(&Config{Driver: POSTGRES, URI: "postgres://localhost:1/test", LazyConnect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUpsertManyValidation(t *testing.T) {
+	store1, store1Copy, store2 := 1, 1, 2
+
+	tests := []struct {
+		name     string
+		slice    interface{}
+		conflict []string
+		wantErr  string
+	}{
+		{"not a slice", &upsertProduct{}, []string{"sku"}, "pointer to a slice of structs"},
+		{"no conflict columns", &[]upsertProduct{{SKU: "a"}}, nil, "conflictColumns cannot be empty"},
+		{"unknown column", &[]upsertProduct{{SKU: "a"}}, []string{"code"}, "column code does not exist"},
+		{"nil row", &[]*upsertProduct{{SKU: "a"}, nil}, []string{"sku"}, "row 1 is nil"},
+		{
+			"duplicate conflict keys",
+			&[]upsertProduct{{SKU: "a", Price: 1}, {SKU: "b"}, {SKU: "a", Price: 2}},
+			[]string{"SKU"},
+			"rows 0 and 2 have the same values",
+		},
+		{
+			"duplicate keys through pointers",
+			&[]upsertProduct{{SKU: "a", Store: &store1}, {SKU: "a", Store: &store2}, {SKU: "a", Store: &store1Copy}},
+			[]string{"sku", "store"},
+			"rows 0 and 2 have the same values",
+		},
+		{"no columns", &[]upsertComputed{{}}, []string{"total"}, "has no columns to insert"},
+	}
+
+	db := lazyORM(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.UpsertMany(tt.slice, tt.conflict, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("UpsertMany() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,429 @@
+package query
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Escapes the LIKE wildcards % and _ and the escape character \ in s,
+// so s matches literally when used in a LIKE pattern.
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Returns a filter matching rows where column is LIKE pattern.
+//
+// % and _ in pattern are wildcards. Escape user input in the literal portion
+// of the pattern with EscapeLike e.g Like("name", EscapeLike(input)+"%").
+// Column names are converted to snake_case.
+func Like(column, pattern string) *QueryFilter {
+	return likeFilter(column, "LIKE", pattern)
+}
+
+// Same as Like but case insensitive
+func ILike(column, pattern string) *QueryFilter {
+	return likeFilter(column, "ILIKE", pattern)
+}
+
+// Returns a filter matching rows where column contains value.
+// Wildcards in value are escaped.
+func Contains(column, value string) *QueryFilter {
+	return Like(column, "%"+EscapeLike(value)+"%")
+}
+
+// Returns a filter matching rows where column starts with value.
+// Wildcards in value are escaped.
+func StartsWith(column, value string) *QueryFilter {
+	return Like(column, EscapeLike(value)+"%")
+}
+
+// Returns a filter matching rows where column ends with value.
+// Wildcards in value are escaped.
+func EndsWith(column, value string) *QueryFilter {
+	return Like(column, "%"+EscapeLike(value))
+}
+
+// Same as Contains but case insensitive
+func IContains(column, value string) *QueryFilter {
+	return ILike(column, "%"+EscapeLike(value)+"%")
+}
+
+// Same as StartsWith but case insensitive
+func IStartsWith(column, value string) *QueryFilter {
+	return ILike(column, EscapeLike(value)+"%")
+}
+
+// Same as EndsWith but case insensitive
+func IEndsWith(column, value string) *QueryFilter {
+	return ILike(column, "%"+EscapeLike(value))
+}
+
+// Returns the filter for column op $1 with the backslash escape character
+func likeFilter(column, op, pattern string) *QueryFilter {
+	return &QueryFilter{
+		Where: fmt.Sprintf(`%s %s $1 ESCAPE '\'`, snakeCase(column), op),
+		Args:  Args{pattern},
+	}
+}
+
+// Returns a filter matching rows where column is between lo and hi inclusive
+// e.g Between("birth_date", from, to) gives birth_date BETWEEN $1 AND $2.
+// Combine it with other conditions using And.
+func Between(column string, lo, hi interface{}) *QueryFilter {
+	return betweenFilter(column, "BETWEEN", lo, hi)
+}
+
+// Returns a filter matching rows where column is not between lo and hi
+func NotBetween(column string, lo, hi interface{}) *QueryFilter {
+	return betweenFilter(column, "NOT BETWEEN", lo, hi)
+}
+
+// Returns the filter for column op $1 AND $2
+func betweenFilter(column, op string, lo, hi interface{}) *QueryFilter {
+	return &QueryFilter{
+		Where: fmt.Sprintf("%s %s $1 AND $2", snakeCase(column), op),
+		Args:  Args{lo, hi},
+	}
+}
+
+// Returns a filter matching rows where column IS NULL.
+// It has no placeholders, so it does not shift the args of conditions combined with it.
+func IsNull(column string) *QueryFilter {
+	return &QueryFilter{Where: snakeCase(column) + " IS NULL"}
+}
+
+// Returns a filter matching rows where column IS NOT NULL
+func IsNotNull(column string) *QueryFilter {
+	return &QueryFilter{Where: snakeCase(column) + " IS NOT NULL"}
+}
+
+// Returns a filter matching rows where each column equals its value in conditions
+// e.g Equal(map[string]interface{}{"name": "bob", "age": 20}) is age = $1 AND name = $2
+// with args [20 bob]. The columns are snake_cased and sorted so the SQL is stable.
+// A nil value matches rows where the column IS NULL.
+func Equal(conditions map[string]interface{}) *QueryFilter {
+	columns := make([]string, 0, len(conditions))
+	for column := range conditions {
+		columns = append(columns, column)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		return snakeCase(columns[i]) < snakeCase(columns[j])
+	})
+
+	filters := make([]*QueryFilter, len(columns))
+	for i, column := range columns {
+		if conditions[column] == nil {
+			filters[i] = IsNull(column)
+		} else {
+			filters[i] = Compare(column, "=", conditions[column])
+		}
+	}
+
+	return And(filters...)
+}
+
+// Returns a filter matching rows whose columns equal the non-zero fields of example,
+// a struct or a pointer to a struct e.g ByExample(&User{Name: "bob", Age: 20})
+// is age = $1 AND name = $2 with args [20 bob]. Field names are snake_cased.
+//
+// Zero valued fields are skipped, so an example can't match zero values
+// e.g age = 0 or active = false. Use Equal or Compare for those.
+// Relations (struct fields with a foreignKey tag) are skipped and fields
+// of embedded structs are included.
+func ByExample(example interface{}) *QueryFilter {
+	v := reflect.Indirect(reflect.ValueOf(example))
+	if v.Kind() != reflect.Struct {
+		return &QueryFilter{err: fmt.Errorf("example must be a struct or pointer to a struct, got %T", example)}
+	}
+
+	conditions := map[string]interface{}{}
+	exampleConditions(v, conditions)
+	if len(conditions) == 0 {
+		return &QueryFilter{err: fmt.Errorf("example %T has no non-zero fields", example)}
+	}
+
+	return Equal(conditions)
+}
+
+// Adds the non-zero exported fields of struct v to conditions
+func exampleConditions(v reflect.Value, conditions map[string]interface{}) {
+	valuer := reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !field.Type.Implements(valuer) {
+			exampleConditions(value, conditions)
+			continue
+		}
+
+		if value.IsZero() || isRelation(field) {
+			continue
+		}
+
+		conditions[field.Name] = value.Interface()
+	}
+}
+
+// Returns true if field is a relation i.e a struct, pointer or slice of structs with a foreignKey tag
+func isRelation(field reflect.StructField) bool {
+	if !strings.Contains(field.Tag.Get("orm"), "foreignKey") {
+		return false
+	}
+
+	t := field.Type
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// Returns a filter matching rows that match all filters.
+//
+// The Where conditions of filters are joined with AND and their placeholders
+// are renumbered in order, so each filter can number its placeholders from $1.
+// e.g And(IsNull("deleted_at"), Between("age", 18, 30), Contains("name", "an")).
+func And(filters ...*QueryFilter) *QueryFilter {
+	return combine("AND", filters)
+}
+
+// Returns a filter matching rows that match any of the filters
+func Or(filters ...*QueryFilter) *QueryFilter {
+	return combine("OR", filters)
+}
+
+// Joins the Where conditions of filters with op and appends their args in order
+func combine(op string, filters []*QueryFilter) *QueryFilter {
+	combined := &QueryFilter{}
+	conditions := []string{}
+
+	for _, filter := range filters {
+		if filter == nil || filter.Where == "" {
+			continue
+		}
+
+		if filter.err != nil && combined.err == nil {
+			combined.err = filter.err
+		}
+
+		conditions = append(conditions, ShiftPlaceholders(filter.Where, len(combined.Args)))
+		combined.Args = append(combined.Args, filter.Args...)
+	}
+
+	if len(conditions) == 1 {
+		combined.Where = conditions[0]
+	} else if len(conditions) > 1 {
+		combined.Where = "(" + strings.Join(conditions, ") "+op+" (") + ")"
+	}
+
+	return combined
+}
+
+// Subquery is a sql query with its own args used as the right hand side of In or Compare.
+// Its placeholders start at $1 and are renumbered when it's merged into a filter.
+type Subquery struct {
+	SQL  string
+	Args Args
+
+	err error
+}
+
+// Returns a raw subquery e.g Sub("SELECT user_id FROM orders WHERE total > $1", 100)
+func Sub(sql string, args ...interface{}) *Subquery {
+	return &Subquery{SQL: sql, Args: args}
+}
+
+// Returns the SELECT statement described by qf as a subquery.
+//
+// qf.Select and qf.From are required unless qf.Query is set e.g
+// (&QueryFilter{Select: []string{"user_id"}, From: "orders", Where: "total > $1", Args: Args{100}}).Subquery()
+func (qf *QueryFilter) Subquery() *Subquery {
+	if qf.Query == nil && (len(qf.Select) == 0 || qf.From == "") {
+		return &Subquery{err: errors.New("subquery requires Select and From")}
+	}
+
+	q := &Query{Filter: qf}
+	if qf.Query == nil {
+		q.Query = fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(qf.Select, ", "), qf.From, qf.JoinClause())
+	}
+
+	q.AddQueryFilters()
+	return &Subquery{SQL: q.Query, Args: q.Args, err: qf.err}
+}
+
+// Comparison operators allowed in Compare
+var comparisonOperators = map[string]bool{
+	"=": true, "<>": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+// Returns a filter matching rows where column op value is true e.g Compare("age", ">=", 18).
+//
+// value may be a *Subquery or a *QueryFilter that is used as a subquery
+// e.g Compare("total", ">", Sub("SELECT avg(total) FROM orders")).
+func Compare(column, op string, value interface{}) *QueryFilter {
+	if !comparisonOperators[op] {
+		return &QueryFilter{err: fmt.Errorf("invalid comparison operator %q", op)}
+	}
+
+	if sub, ok := subquery(value); ok {
+		return &QueryFilter{
+			Where: fmt.Sprintf("%s %s (%s)", snakeCase(column), op, sub.SQL),
+			Args:  append(Args{}, sub.Args...),
+			err:   sub.err,
+		}
+	}
+
+	return &QueryFilter{
+		Where: fmt.Sprintf("%s %s $1", snakeCase(column), op),
+		Args:  Args{value},
+	}
+}
+
+// Returns a filter matching rows where column is one of values.
+//
+// If values is a single *Subquery or *QueryFilter, column is matched against
+// the rows of the subquery e.g In("id", Sub("SELECT user_id FROM orders WHERE total > $1", 100)).
+// Slice and array values are expanded into their elements, so In("id", ids) and
+// In("id", 1, 2, 3) are the same. Byte slices and driver.Valuers are single values.
+// An empty list of values matches no rows.
+func In(column string, values ...interface{}) *QueryFilter {
+	return inFilter(column, "IN", values)
+}
+
+// Returns a filter matching rows where column is not one of values.
+// An empty list of values matches all rows.
+func NotIn(column string, values ...interface{}) *QueryFilter {
+	return inFilter(column, "NOT IN", values)
+}
+
+// Returns the filter for column op (values) or column op (subquery)
+func inFilter(column, op string, values []interface{}) *QueryFilter {
+	if len(values) == 1 {
+		if sub, ok := subquery(values[0]); ok {
+			return &QueryFilter{
+				Where: fmt.Sprintf("%s %s (%s)", snakeCase(column), op, sub.SQL),
+				Args:  append(Args{}, sub.Args...),
+				err:   sub.err,
+			}
+		}
+	}
+
+	values = expandValues(values)
+	if len(values) == 0 {
+		if op == "IN" {
+			return &QueryFilter{Where: "FALSE"}
+		}
+		return &QueryFilter{Where: "TRUE"}
+	}
+
+	placeholders := make([]string, len(values))
+	for i := range values {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	return &QueryFilter{
+		Where: fmt.Sprintf("%s %s (%s)", snakeCase(column), op, strings.Join(placeholders, ", ")),
+		Args:  append(Args{}, values...),
+	}
+}
+
+// Returns values with slices and arrays replaced by their elements.
+// Byte slices e.g uuid.UUID and driver.Valuers are kept as single values.
+func expandValues(values []interface{}) []interface{} {
+	expanded := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		v := reflect.ValueOf(value)
+		if _, ok := value.(driver.Valuer); ok || !v.IsValid() ||
+			(v.Kind() != reflect.Slice && v.Kind() != reflect.Array) || v.Type().Elem().Kind() == reflect.Uint8 {
+			expanded = append(expanded, value)
+			continue
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			expanded = append(expanded, v.Index(i).Interface())
+		}
+	}
+	return expanded
+}
+
+// Returns value as a subquery if it's a *Subquery or *QueryFilter
+func subquery(value interface{}) (*Subquery, bool) {
+	switch v := value.(type) {
+	case *Subquery:
+		return v, v != nil
+	case *QueryFilter:
+		return v.Subquery(), v != nil
+	}
+	return nil, false
+}
+
+// Returns a filter matching rows where the jsonb column contains value e.g
+// JSONContains("details", map[string]any{"role": "admin"}) gives details @> $1.
+// value is encoded to JSON.
+func JSONContains(column string, value interface{}) *QueryFilter {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return &QueryFilter{err: fmt.Errorf("encoding JSONContains value: %w", err)}
+	}
+
+	return &QueryFilter{
+		Where: fmt.Sprintf("%s @> $1", snakeCase(column)),
+		Args:  Args{string(data)},
+	}
+}
+
+// Returns the expression extracting the text at path from the json column e.g
+// JSONField("details", "username") gives details->>'username' and
+// JSONField("details", "address", "city") gives details->'address'->>'city'.
+//
+// The expression can be used as the column of other conditions e.g Compare(JSONField("details", "age"), ">", "18").
+func JSONField(column string, path ...string) string {
+	expr := snakeCase(column)
+	for i, key := range path {
+		op := "->"
+		if i == len(path)-1 {
+			op = "->>"
+		}
+		expr += op + "'" + strings.ReplaceAll(key, "'", "''") + "'"
+	}
+	return expr
+}
+
+// Returns a filter matching rows where the tsvector column matches the text search query tsquery
+// e.g FullTextMatch("search", "rat & cat") gives search @@ to_tsquery($1).
+// tsquery uses the to_tsquery syntax with the operators &, |, ! and <->.
+func FullTextMatch(column, tsquery string) *QueryFilter {
+	return &QueryFilter{
+		Where: fmt.Sprintf("%s @@ to_tsquery($1)", snakeCase(column)),
+		Args:  Args{tsquery},
+	}
+}
+
+// Returns a filter matching rows where the array column has an element equal to value
+// e.g ArrayContains("privileges", "admin") gives $1 = ANY(privileges).
+func ArrayContains(column string, value interface{}) *QueryFilter {
+	return &QueryFilter{
+		Where: fmt.Sprintf("$1 = ANY(%s)", snakeCase(column)),
+		Args:  Args{value},
+	}
+}
+
+// Returns a filter matching rows where the array column has at least one element in common
+// with values e.g ArrayOverlaps("privileges", pq.StringArray{"read", "write"}) gives privileges && $1.
+// values is a slice or array type such as []string or pq.Int64Array.
+func ArrayOverlaps(column string, values interface{}) *QueryFilter {
+	return &QueryFilter{
+		Where: fmt.Sprintf("%s && $1", snakeCase(column)),
+		Args:  Args{values},
+	}
+}
@@ -0,0 +1,259 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Fails t if filter does not have the where condition and args
+func assertFilter(t *testing.T, filter *QueryFilter, where string, args Args) {
+	t.Helper()
+
+	if err := filter.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if filter.Where != where {
+		t.Errorf("Where = %q, want %q", filter.Where, where)
+	}
+
+	// nil and empty args are the same
+	if (len(filter.Args) > 0 || len(args) > 0) && !reflect.DeepEqual(filter.Args, args) {
+		t.Errorf("Args = %v, want %v", filter.Args, args)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"abc", "abc"},
+		{"50%", `50\%`},
+		{"first_name", `first\_name`},
+		{`C:\dir`, `C:\\dir`},
+		{`%_\`, `\%\_\\`},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeLike(tt.input); got != tt.want {
+			t.Errorf("EscapeLike(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLikeFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  *QueryFilter
+		where   string
+		pattern string
+	}{
+		{"Like", Like("FirstName", "jo%"), `first_name LIKE $1 ESCAPE '\'`, "jo%"},
+		{"ILike", ILike("name", "%jo"), `name ILIKE $1 ESCAPE '\'`, "%jo"},
+		{"Contains", Contains("name", "50%"), `name LIKE $1 ESCAPE '\'`, `%50\%%`},
+		{"StartsWith", StartsWith("name", "a_b"), `name LIKE $1 ESCAPE '\'`, `a\_b%`},
+		{"EndsWith", EndsWith("name", "son"), `name LIKE $1 ESCAPE '\'`, "%son"},
+		{"IContains", IContains("name", "an"), `name ILIKE $1 ESCAPE '\'`, "%an%"},
+		{"IStartsWith", IStartsWith("name", "an"), `name ILIKE $1 ESCAPE '\'`, "an%"},
+		{"IEndsWith", IEndsWith("name", "an"), `name ILIKE $1 ESCAPE '\'`, "%an"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFilter(t, tt.filter, tt.where, Args{tt.pattern})
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	assertFilter(t, Between("BirthDate", "2000-01-01", "2000-12-31"),
+		"birth_date BETWEEN $1 AND $2", Args{"2000-01-01", "2000-12-31"})
+
+	assertFilter(t, NotBetween("age", 18, 30), "age NOT BETWEEN $1 AND $2", Args{18, 30})
+}
+
+func TestIsNull(t *testing.T) {
+	assertFilter(t, IsNull("DeletedAt"), "deleted_at IS NULL", nil)
+	assertFilter(t, IsNotNull("email"), "email IS NOT NULL", nil)
+
+	// Null conditions have no placeholders, so the placeholders
+	// of the conditions around them are numbered without gaps
+	tests := []struct {
+		name   string
+		filter *QueryFilter
+		where  string
+		args   Args
+	}{
+		{
+			"null first",
+			And(IsNull("deleted_at"), Between("age", 18, 30), Contains("name", "an")),
+			`(deleted_at IS NULL) AND (age BETWEEN $1 AND $2) AND (name LIKE $3 ESCAPE '\')`,
+			Args{18, 30, "%an%"},
+		},
+		{
+			"null between values",
+			And(Like("name", "b%"), IsNotNull("email"), NotBetween("age", 18, 30)),
+			`(name LIKE $1 ESCAPE '\') AND (email IS NOT NULL) AND (age NOT BETWEEN $2 AND $3)`,
+			Args{"b%", 18, 30},
+		},
+		{
+			"or",
+			Or(IsNull("email"), EndsWith("email", "@example.com")),
+			`(email IS NULL) OR (email LIKE $1 ESCAPE '\')`,
+			Args{"%@example.com"},
+		},
+		{
+			"only null conditions",
+			And(IsNull("deleted_at"), IsNotNull("email")),
+			"(deleted_at IS NULL) AND (email IS NOT NULL)",
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFilter(t, tt.filter, tt.where, tt.args)
+		})
+	}
+}
+
+func TestIn(t *testing.T) {
+	id := [16]byte{1}
+
+	tests := []struct {
+		name   string
+		filter *QueryFilter
+		where  string
+		args   Args
+	}{
+		{"values", In("id", 1, 2, 3), "id IN ($1, $2, $3)", Args{1, 2, 3}},
+		{"slice", In("id", []int{1, 2, 3}), "id IN ($1, $2, $3)", Args{1, 2, 3}},
+		{"array", In("id", [2]string{"a", "b"}), "id IN ($1, $2)", Args{"a", "b"}},
+		{"slice and values", In("id", []int64{1, 2}, int64(3)), "id IN ($1, $2, $3)", Args{int64(1), int64(2), int64(3)}},
+		{"byte slice", In("data", []byte("ab")), "data IN ($1)", Args{[]byte("ab")}},
+		{"byte array", In("uuid", id), "uuid IN ($1)", Args{id}},
+		{"not in", NotIn("UserID", []int{4, 5}), "user_id NOT IN ($1, $2)", Args{4, 5}},
+		{"empty in", In("id"), "FALSE", nil},
+		{"empty slice", In("id", []int{}), "FALSE", nil},
+		{"empty not in", NotIn("id", []string{}), "TRUE", nil},
+		{
+			"subquery",
+			In("id", Sub("SELECT user_id FROM orders WHERE total > $1", 100)),
+			"id IN (SELECT user_id FROM orders WHERE total > $1)",
+			Args{100},
+		},
+		{
+			"filter subquery",
+			NotIn("id", &QueryFilter{Select: []string{"user_id"}, From: "orders", Where: "total > $1", Args: Args{100}}),
+			"id NOT IN (SELECT user_id FROM orders WHERE total > $1)",
+			Args{100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFilter(t, tt.filter, tt.where, tt.args)
+		})
+	}
+
+	// The placeholders of the subquery are renumbered after the preceding filters
+	assertFilter(t, And(Compare("active", "=", true), In("id", Sub("SELECT user_id FROM orders WHERE total > $1", 100))),
+		"(active = $1) AND (id IN (SELECT user_id FROM orders WHERE total > $2))", Args{true, 100})
+
+	if err := In("id", (&QueryFilter{Where: "total > $1"})).Validate(); err == nil {
+		t.Error("In with a subquery without Select and From returned no error")
+	}
+}
+
+func TestFullTextMatch(t *testing.T) {
+	assertFilter(t, FullTextMatch("Search", "rat & !cat"), "search @@ to_tsquery($1)", Args{"rat & !cat"})
+
+	// The tsquery is a placeholder renumbered like any other argument
+	assertFilter(t, And(IsNull("deleted_at"), Like("title", "a%"), FullTextMatch("search", "rat")),
+		`(deleted_at IS NULL) AND (title LIKE $1 ESCAPE '\') AND (search @@ to_tsquery($2))`, Args{"a%", "rat"})
+}
+
+func TestArrayFilters(t *testing.T) {
+	assertFilter(t, ArrayContains("Privileges", "admin"), "$1 = ANY(privileges)", Args{"admin"})
+
+	// The values are one array argument, not expanded like In
+	assertFilter(t, ArrayOverlaps("tags", []string{"go", "sql"}), "tags && $1", Args{[]string{"go", "sql"}})
+
+	assertFilter(t, And(Compare("active", "=", true), ArrayContains("privileges", "admin"), ArrayOverlaps("tags", []int64{1, 2})),
+		"(active = $1) AND ($2 = ANY(privileges)) AND (tags && $3)", Args{true, "admin", []int64{1, 2}})
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions map[string]interface{}
+		where      string
+		args       Args
+	}{
+		{"single", map[string]interface{}{"Name": "bob"}, "name = $1", Args{"bob"}},
+		{
+			"sorted by column",
+			map[string]interface{}{"name": "bob", "age": 20, "CreatedBy": 1},
+			"(age = $1) AND (created_by = $2) AND (name = $3)",
+			Args{20, 1, "bob"},
+		},
+		{"nil is null", map[string]interface{}{"deleted_at": nil, "name": "bob"}, "(deleted_at IS NULL) AND (name = $1)", Args{"bob"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFilter(t, Equal(tt.conditions), tt.where, tt.args)
+		})
+	}
+}
+
+type ExampleBase struct {
+	ID int
+}
+
+type exampleProfile struct {
+	UserID int
+}
+
+type exampleUser struct {
+	ExampleBase
+	Name    string
+	Age     int
+	Active  bool
+	Email   *string
+	Profile exampleProfile `orm:"foreignKey:UserID->ID"`
+	secret  string
+}
+
+func TestByExample(t *testing.T) {
+	email := "bob@example.com"
+
+	tests := []struct {
+		name    string
+		example interface{}
+		where   string
+		args    Args
+	}{
+		{"struct", exampleUser{Name: "bob", Age: 20}, "(age = $1) AND (name = $2)", Args{20, "bob"}},
+		{"pointer", &exampleUser{Name: "bob"}, "name = $1", Args{"bob"}},
+		{"embedded fields", exampleUser{ExampleBase: ExampleBase{ID: 7}, Active: true}, "(active = $1) AND (id = $2)", Args{true, 7}},
+		{"pointer field", exampleUser{Email: &email}, "email = $1", Args{&email}},
+		{"relations and unexported fields are skipped", exampleUser{Name: "bob", Profile: exampleProfile{UserID: 1}, secret: "x"}, "name = $1", Args{"bob"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFilter(t, ByExample(tt.example), tt.where, tt.args)
+		})
+	}
+
+	if err := ByExample(exampleUser{}).Validate(); err == nil {
+		t.Error("ByExample with only zero fields returned no error")
+	}
+
+	if err := ByExample("bob").Validate(); err == nil {
+		t.Error("ByExample with a string returned no error")
+	}
+}
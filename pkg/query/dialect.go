@@ -0,0 +1,103 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect writes the database specific parts of sql statements.
+//
+// Statements are built with $n placeholders and converted to the
+// placeholders of the dialect with Rebind.
+type Dialect interface {
+	// Returns the placeholder of the nth argument, starting at 1 e.g $1 or ?
+	Placeholder(n int) string
+
+	// Returns s quoted as an identifier e.g "users" or `users`
+	QuoteIdent(s string) string
+}
+
+// Postgres uses numbered placeholders $1, $2 and double quoted identifiers
+type postgresDialect struct{}
+
+func (postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (postgresDialect) QuoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// MySQL uses positional ? placeholders and backtick quoted identifiers
+type mysqlDialect struct{}
+
+func (mysqlDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (mysqlDialect) QuoteIdent(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+// SQLite uses numbered placeholders ?1, ?2 and double quoted identifiers
+type sqliteDialect struct{}
+
+func (sqliteDialect) Placeholder(n int) string {
+	return "?" + strconv.Itoa(n)
+}
+
+func (sqliteDialect) QuoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+var (
+	Postgres Dialect = postgresDialect{}
+	MySQL    Dialect = mysqlDialect{}
+	SQLite   Dialect = sqliteDialect{}
+)
+
+// Returns the dialect of the driver name: postgres, mysql or sqlite.
+// An empty driver name is postgres.
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case "postgres", "":
+		return Postgres, nil
+	case "mysql":
+		return MySQL, nil
+	case "sqlite":
+		return SQLite, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidDriver, driver)
+}
+
+// Returns sql with its $n placeholders replaced by the placeholders of d and the args for them.
+//
+// For dialects with positional placeholders e.g ?, the args are reordered to match
+// the order the placeholders appear in sql, so $n can be used in any order or more than once.
+func Rebind(d Dialect, sql string, args Args) (string, Args) {
+	if d == Postgres {
+		return sql, args
+	}
+
+	positional := d.Placeholder(1) == d.Placeholder(2)
+	if !positional {
+		return placeholderRegex.ReplaceAllStringFunc(sql, func(placeholder string) string {
+			n, _ := strconv.Atoi(placeholder[1:])
+			return d.Placeholder(n)
+		}), args
+	}
+
+	ordered := Args{}
+	sql = placeholderRegex.ReplaceAllStringFunc(sql, func(placeholder string) string {
+		n, _ := strconv.Atoi(placeholder[1:])
+		if n < 1 || n > len(args) {
+			return placeholder
+		}
+
+		ordered = append(ordered, args[n-1])
+		return d.Placeholder(len(ordered))
+	})
+
+	return sql, ordered
+}
@@ -0,0 +1,110 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDialectFor(t *testing.T) {
+	tests := []struct {
+		driver string
+		want   Dialect
+	}{
+		{"postgres", Postgres},
+		{"", Postgres},
+		{"mysql", MySQL},
+		{"sqlite", SQLite},
+	}
+
+	for _, tt := range tests {
+		got, err := DialectFor(tt.driver)
+		if err != nil || got != tt.want {
+			t.Errorf("DialectFor(%q) = %v, %v, want %v", tt.driver, got, err, tt.want)
+		}
+	}
+
+	if _, err := DialectFor("oracle"); !errors.Is(err, ErrInvalidDriver) {
+		t.Errorf("DialectFor(oracle) error = %v, want ErrInvalidDriver", err)
+	}
+}
+
+func TestDialectQuoteIdent(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		name    string
+		want    string
+	}{
+		{Postgres, "users", `"users"`},
+		{Postgres, `my"table`, `"my""table"`},
+		{SQLite, "order", `"order"`},
+		{MySQL, "users", "`users`"},
+		{MySQL, "my`table", "`my``table`"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.QuoteIdent(tt.name); got != tt.want {
+			t.Errorf("%T.QuoteIdent(%q) = %s, want %s", tt.dialect, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		sql      string
+		args     Args
+		wantSQL  string
+		wantArgs Args
+	}{
+		{
+			"postgres is unchanged",
+			Postgres, "name = $1 AND age > $2", Args{"bob", 18},
+			"name = $1 AND age > $2", Args{"bob", 18},
+		},
+		{
+			"sqlite numbered placeholders",
+			SQLite, "name = $1 AND age > $2", Args{"bob", 18},
+			"name = ?1 AND age > ?2", Args{"bob", 18},
+		},
+		{
+			"mysql positional placeholders",
+			MySQL, "name = $1 AND age > $2", Args{"bob", 18},
+			"name = ? AND age > ?", Args{"bob", 18},
+		},
+		{
+			"mysql reorders args",
+			MySQL, "age > $2 AND name = $1", Args{"bob", 18},
+			"age > ? AND name = ?", Args{18, "bob"},
+		},
+		{
+			"mysql repeats args",
+			MySQL, "a = $1 OR b = $1", Args{"x"},
+			"a = ? OR b = ?", Args{"x", "x"},
+		},
+		{
+			"mysql keeps placeholders without args",
+			MySQL, "a = $1 AND b = $3", Args{"x"},
+			"a = ? AND b = $3", Args{"x"},
+		},
+		{
+			"sqlite ten or more args",
+			SQLite, "a IN ($1, $10)", Args{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			"a IN (?1, ?10)", Args{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := Rebind(tt.dialect, tt.sql, tt.args)
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %q, want %q", sql, tt.wantSQL)
+			}
+
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matches identifiers e.g name, created_at or users.name
+var identRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Returns name double quoted for use as an identifier in raw sql e.g users.name -> "users"."name".
+//
+// Use it for column or table names chosen at runtime e.g from an API request.
+// Returns an error if name is not a plain identifier, optionally qualified with a table name.
+// Quoted identifiers are case sensitive, so name must match the column exactly.
+func Ident(name string) (string, error) {
+	if !identRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid identifier %q", name)
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = Postgres.QuoteIdent(part)
+	}
+
+	return strings.Join(parts, "."), nil
+}
+
+// Returns an ORDER BY clause (without the ORDER BY keyword) for userInput
+// that can be assigned to QueryFilter.OrderBy.
+//
+// userInput is a comma separated list of columns, each optionally followed by asc or desc
+// or prefixed with - for descending order e.g "name", "-created_at" or "name desc, id".
+// Each column must be a key in allowed that is set to true.
+// Returns an error for columns that are not allowed or invalid input.
+func SortBy(allowed map[string]bool, userInput string) (string, error) {
+	clauses := []string{}
+
+	for _, part := range strings.Split(userInput, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 || len(fields) > 2 {
+			return "", fmt.Errorf("invalid sort %q", part)
+		}
+
+		column, direction := fields[0], "ASC"
+		if strings.HasPrefix(column, "-") && len(fields) == 1 {
+			column, direction = column[1:], "DESC"
+		}
+
+		if len(fields) == 2 {
+			direction = strings.ToUpper(fields[1])
+			if direction != "ASC" && direction != "DESC" {
+				return "", fmt.Errorf("invalid sort direction %q", fields[1])
+			}
+		}
+
+		if !allowed[column] {
+			return "", fmt.Errorf("sorting by %q is not allowed", column)
+		}
+
+		ident, err := Ident(column)
+		if err != nil {
+			return "", err
+		}
+
+		clauses = append(clauses, ident+" "+direction)
+	}
+
+	return strings.Join(clauses, ", "), nil
+}
@@ -0,0 +1,89 @@
+package query
+
+import "testing"
+
+func TestIdent(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"name", `"name"`, false},
+		{"created_at", `"created_at"`, false},
+		{"CreatedAt", `"CreatedAt"`, false},
+		{"users.name", `"users"."name"`, false},
+		{"_id", `"_id"`, false},
+		{"", "", true},
+		{"name; drop table users", "", true},
+		{`name"`, "", true},
+		{`"name"`, "", true},
+		{`users."name"`, "", true},
+		{"name--", "", true},
+		{"name -- comment", "", true},
+		{"1name", "", true},
+		{"public.users.name", "", true},
+		{"name ", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Ident(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Ident(%q) error = %v, wantErr %t", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("Ident(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	allowed := map[string]bool{"name": true, "created_at": true, "users.id": true, `bad"column`: true, "email": false}
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"name", `"name" ASC`, false},
+		{"-created_at", `"created_at" DESC`, false},
+		{"name desc", `"name" DESC`, false},
+		{"name DESC, created_at", `"name" DESC, "created_at" ASC`, false},
+		{" name  asc ,users.id dEsC", `"name" ASC, "users"."id" DESC`, false},
+
+		// Unknown sort keys
+		{"age", "", true},
+		{"email", "", true},
+		{"Name", "", true},
+		{"name;drop", "", true},
+		{"name--", "", true},
+
+		// Invalid directions
+		{"name up", "", true},
+		{"name desc;", "", true},
+		{"-name desc", "", true},
+		{"name desc nulls", "", true},
+		{"name; drop table users", "", true},
+
+		// Empty columns
+		{"", "", true},
+		{"name,", "", true},
+		{"-", "", true},
+
+		// Allowed keys must still be plain identifiers
+		{`bad"column`, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := SortBy(allowed, tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SortBy(%q) error = %v, wantErr %t", tt.input, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("SortBy(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
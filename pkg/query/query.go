@@ -4,9 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/georgysavva/scany/pgxscan"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/iancoleman/strcase"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 )
 
 var (
@@ -22,12 +30,20 @@ var (
 // Args is an alias for a slice of empty interface
 type Args []interface{}
 
+// Conn runs queries on the database.
+// It is implemented by *pgxpool.Pool and pgx.Tx so queries can run in a transaction.
+type Conn interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
 // Encapsulates a pgxpool.Pool and runs queries
 type Query struct {
 	// The database driver
 	Driver string
-	// The database connection string
-	Pool *pgxpool.Pool
+	// The connection pool or transaction the query runs on
+	Pool Conn
 
 	// The query string
 	Query string
@@ -44,8 +60,20 @@ type Query struct {
 	// The query error
 	Error error
 
+	// Number of rows affected by Exec or returned by Create
+	RowsAffected int64
+
 	// The query context
 	Context context.Context
+
+	// Maximum duration of the query if Context has no deadline. Zero means no timeout
+	Timeout time.Duration
+
+	// Name of the operation that runs the query e.g User.Create, written with the query to Logger
+	Label string
+
+	// Writer the query is logged to. Defaults to os.Stdout
+	Logger io.Writer
 }
 
 // QueryFilters stores query filter clause with arguments to
@@ -55,18 +83,52 @@ type QueryFilter struct {
 	// User defined raw query. Overrides the query.Query.Query field
 	Query *string
 
+	// Columns or expressions to select instead of the model columns
+	// e.g "users.name", "count(orders.id) AS order_count"
+	Select []string
+
+	// Table expression to select from instead of the model table
+	// e.g "users INNER JOIN orders ON orders.user_id = users.id".
+	// Use it with Select to scan joins and aggregates into a projection struct.
+	From string
+
+	// Joins inserted after the FROM clause of select queries
+	// e.g "INNER JOIN profiles ON profiles.user_id = users.id".
+	// The selected columns stay qualified to the model table.
+	Joins []string
+
 	// Where condition
 	Where string
 
 	// Arguments for placeholders in Where clause. Must be equal
 	Args Args
 
+	// Columns to group by. Column names are converted to snake_case.
+	GroupBy []string
+
+	// Having condition applied to the groups e.g count(*) > $1
+	// Placeholders start at $1 and are renumbered after the Where arguments.
+	Having string
+
+	// Arguments for placeholders in Having clause
+	HavingArgs Args
+
+	// Order by clause without the ORDER BY keyword e.g "created_at DESC, id"
+	OrderBy string
+
+	// Maximum number of rows returned. Zero means no limit
+	Limit int
+
+	// Number of rows skipped before returning rows
+	Offset int
+
 	// Keeps track of error while validating the query
 	err error
 }
 
 // If the QueryFilter is nil, it returns ErrEmptyQueryFilter. If Where is empty, it returns ErrEmptyQueryFilterWhere.
-// If len(qf.Args) ==0, it returns ErrEmptyQueryFilterArgs
+// If Where has placeholders and len(qf.Args) ==0, it returns ErrEmptyQueryFilterArgs.
+// Conditions without placeholders e.g deleted_at IS NULL need no args.
 func (qf *QueryFilter) Validate() error {
 	if qf == nil {
 		return ErrEmptyQueryFilter
@@ -80,13 +142,54 @@ func (qf *QueryFilter) Validate() error {
 		return ErrEmptyQueryFilterWhere
 	}
 
-	if len(qf.Args) == 0 {
+	if len(qf.Args) == 0 && placeholderRegex.MatchString(qf.Where) {
 		return ErrEmptyQueryFilterArgs
 	}
 
 	return nil
 }
 
+// Returns a copy of qf. If qf is nil, it returns an empty QueryFilter
+func (qf *QueryFilter) Clone() *QueryFilter {
+	if qf == nil {
+		return &QueryFilter{}
+	}
+
+	clone := *qf
+	clone.Args = append(Args{}, qf.Args...)
+	clone.GroupBy = append([]string{}, qf.GroupBy...)
+	clone.HavingArgs = append(Args{}, qf.HavingArgs...)
+	clone.Select = append([]string{}, qf.Select...)
+	clone.Joins = append([]string{}, qf.Joins...)
+	return &clone
+}
+
+// Returns the join clauses of qf with a leading empty space or an empty string if there are no joins
+func (qf *QueryFilter) JoinClause() string {
+	if qf == nil || len(qf.Joins) == 0 {
+		return ""
+	}
+	return " " + strings.Join(qf.Joins, " ")
+}
+
+// Returns a copy of qf with clause ANDed to the Where condition.
+//
+// Placeholders in clause start at $1 and are renumbered after the existing Args.
+// e.g qf.And("age > $1", 20) on a filter with Where "name = $1" gives "(name = $1) AND (age > $2)".
+func (qf *QueryFilter) And(clause string, args ...interface{}) *QueryFilter {
+	clone := qf.Clone()
+	clause = ShiftPlaceholders(clause, len(clone.Args))
+
+	if clone.Where == "" {
+		clone.Where = clause
+	} else {
+		clone.Where = fmt.Sprintf("(%s) AND (%s)", clone.Where, clause)
+	}
+
+	clone.Args = append(clone.Args, args...)
+	return clone
+}
+
 func (query *Query) AddQueryFilters() {
 	if query.Filter == nil {
 		return
@@ -96,26 +199,84 @@ func (query *Query) AddQueryFilters() {
 		query.Query = *(query.Filter.Query)
 	}
 
-	if query.Filter.Where != "" && len(query.Filter.Args) > 0 {
-		query.Query += " WHERE " + query.Filter.Where
+	// The placeholders of the filter are numbered after the args of the query
+	if query.Filter.Where != "" {
+		query.Query += " WHERE " + ShiftPlaceholders(query.Filter.Where, len(query.Args))
 		query.Args = append(query.Args, query.Filter.Args...)
 	}
 
+	if len(query.Filter.GroupBy) > 0 {
+		columns := make([]string, len(query.Filter.GroupBy))
+		for i, column := range query.Filter.GroupBy {
+			columns[i] = snakeCase(column)
+		}
+		query.Query += " GROUP BY " + strings.Join(columns, ", ")
+	}
+
+	if query.Filter.Having != "" {
+		query.Query += " HAVING " + ShiftPlaceholders(query.Filter.Having, len(query.Args))
+		query.Args = append(query.Args, query.Filter.HavingArgs...)
+	}
+
+	if query.Filter.OrderBy != "" {
+		query.Query += " ORDER BY " + query.Filter.OrderBy
+	}
+
+	if query.Filter.Limit > 0 {
+		query.Query += fmt.Sprintf(" LIMIT %d", query.Filter.Limit)
+	}
+
+	if query.Filter.Offset > 0 {
+		query.Query += fmt.Sprintf(" OFFSET %d", query.Filter.Offset)
+	}
+
+	// Filters are written with $n placeholders
+	if d, err := DialectFor(query.Driver); err == nil {
+		query.Query, query.Args = Rebind(d, query.Query, query.Args)
+	}
+}
+
+// Matches $n placeholders
+var placeholderRegex = regexp.MustCompile(`\$(\d+)`)
+
+// ShiftPlaceholders renumbers the $n placeholders in clause by offset.
+//
+// e.g ShiftPlaceholders("a = $1 AND b = $2", 2) returns "a = $3 AND b = $4"
+func ShiftPlaceholders(clause string, offset int) string {
+	if offset == 0 {
+		return clause
+	}
+
+	return placeholderRegex.ReplaceAllStringFunc(clause, func(placeholder string) string {
+		n, _ := strconv.Atoi(placeholder[1:])
+		return "$" + strconv.Itoa(n+offset)
+	})
+}
+
+// Converts a column name to snake_case.
+// Each part of a table qualified column e.g users.createdAt is converted separately.
+// Expressions e.g details->>'name' or lower(name) are returned as is.
+func snakeCase(column string) string {
+	if !identRegex.MatchString(column) {
+		return column
+	}
+
+	parts := strings.Split(column, ".")
+	for i, part := range parts {
+		parts[i] = strcase.ToSnake(part)
+	}
+	return strings.Join(parts, ".")
 }
 
 // Validates the query to make sure it has been instanciated with a good(not nil)
-// Connection Pool, Query and Result struct.
+// Connection Pool, Query and Result struct. The first failed check is set as q.Error.
 // If the query context is nil, validate sets context.Background() on the query
 func (q *Query) Validate() {
 	if q.Pool == nil {
 		q.Error = ErrConnEmpty
-	}
-
-	if q.Query == "" {
+	} else if q.Query == "" {
 		q.Error = ErrQueryEmpty
-	}
-
-	if q.Result == nil {
+	} else if q.Result == nil {
 		q.Error = ErrResultEmpty
 	}
 
@@ -124,6 +285,36 @@ func (q *Query) Validate() {
 	}
 }
 
+// Writes the query and its args to q.Logger, prefixed with q.Label if it's set
+func (q *Query) log() {
+	w := q.Logger
+	if w == nil {
+		w = os.Stdout
+	}
+
+	if q.Label != "" {
+		fmt.Fprintf(w, "[query] %s: %s %v\n\n", q.Label, q.Query, q.Args)
+		return
+	}
+
+	fmt.Fprintf(w, "[query] %s %v\n\n", q.Query, q.Args)
+}
+
+// Returns the context the query runs with.
+// If q.Timeout is set and q.Context has no deadline, the context is cancelled after q.Timeout.
+// The returned cancel func must always be called.
+func (q *Query) runContext() (context.Context, context.CancelFunc) {
+	if q.Timeout <= 0 {
+		return q.Context, func() {}
+	}
+
+	if _, ok := q.Context.Deadline(); ok {
+		return q.Context, func() {}
+	}
+
+	return context.WithTimeout(q.Context, q.Timeout)
+}
+
 // Scans all rows in query Result
 func (q *Query) ScanAll() error {
 	q.Validate()
@@ -132,18 +323,82 @@ func (q *Query) ScanAll() error {
 		return q.Error
 	}
 
-	conn, err := q.Pool.Acquire(q.Context)
+	q.AddQueryFilters()
+
+	ctx, cancel := q.runContext()
+	defer cancel()
+
+	q.log()
+	return pgxscan.Select(ctx, q.Pool, q.Result, q.Query, q.Args...)
+
+}
+
+// Scans all rows into q.Result, which must be a *[]map[string]interface{}.
+// Each row is a map keyed by column name. NULL values are stored as nil.
+func (q *Query) ScanMaps() error {
+	result, ok := q.Result.(*[]map[string]interface{})
+	if !ok {
+		return errors.New("result must be a *[]map[string]interface{}")
+	}
+
+	maps := []map[string]interface{}{}
+	err := q.ScanRows(func(rows pgx.Rows) error {
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+
+		fields := rows.FieldDescriptions()
+		row := make(map[string]interface{}, len(fields))
+		for i, field := range fields {
+			row[string(field.Name)] = values[i]
+		}
+		maps = append(maps, row)
+		return nil
+	})
+
 	if err != nil {
 		return err
 	}
 
-	defer conn.Release()
+	*result = maps
+	return nil
+}
+
+// Runs the query and calls scan for each row.
+// Use it to scan rows that don't map to q.Result, which may be nil.
+// Stops and returns the error of scan if it fails.
+func (q *Query) ScanRows(scan func(rows pgx.Rows) error) error {
+	q.Validate()
+
+	// The rows are read by scan, so the result may be nil
+	if q.Error == ErrResultEmpty {
+		q.Error = nil
+	}
+
+	if q.Error != nil {
+		return q.Error
+	}
 
 	q.AddQueryFilters()
 
-	fmt.Printf("[query] %s %v\n\n", q.Query, q.Args)
-	return pgxscan.Select(q.Context, q.Pool, q.Result, q.Query, q.Args...)
+	ctx, cancel := q.runContext()
+	defer cancel()
+
+	q.log()
+	rows, err := q.Pool.Query(ctx, q.Query, q.Args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
 
+	return rows.Err()
 }
 
 // Scans a single row into the query result
@@ -154,34 +409,47 @@ func (q *Query) ScanOne() error {
 		return q.Error
 	}
 
-	conn, err := q.Pool.Acquire(q.Context)
-	if err != nil {
-		return err
-	}
-
-	defer conn.Release()
-
 	q.AddQueryFilters()
 
-	fmt.Printf("[query] %s %v\n\n", q.Query, q.Args)
-	return pgxscan.Get(q.Context, q.Pool, q.Result, q.Query, q.Args...)
+	ctx, cancel := q.runContext()
+	defer cancel()
+
+	q.log()
+	return pgxscan.Get(ctx, q.Pool, q.Result, q.Query, q.Args...)
 }
 
-// Executes query q expecting no return values
+// Executes query q expecting no return values.
+// The number of affected rows is stored in q.RowsAffected.
 func (q *Query) Exec() error {
 	q.Validate()
 
+	// Exec does not scan any rows, so the result may be nil
+	if q.Error == ErrResultEmpty {
+		q.Error = nil
+	}
+
 	if q.Error != nil {
 		return q.Error
 	}
 
 	q.AddQueryFilters()
-	fmt.Printf("[query] %s %v\n\n", q.Query, q.Args)
-	_, err := q.Pool.Exec(q.Context, q.Query, q.Args...)
-	return err
+	ctx, cancel := q.runContext()
+	defer cancel()
+
+	q.log()
+	tag, err := q.Pool.Exec(ctx, q.Query, q.Args...)
+	if err != nil {
+		return err
+	}
+
+	q.RowsAffected = tag.RowsAffected()
+	return nil
 }
 
-// Executes the query and inserts new records into the database
+// Executes the query and inserts new records into the database.
+//
+// A write that returns no row e.g an insert skipped by ON CONFLICT DO NOTHING
+// is not an error. q.RowsAffected is 0 then and 1 if a row was scanned into the result.
 func (q *Query) Create() error {
 	q.Validate()
 
@@ -189,15 +457,21 @@ func (q *Query) Create() error {
 		return q.Error
 	}
 
-	conn, err := q.Pool.Acquire(q.Context)
+	ctx, cancel := q.runContext()
+	defer cancel()
+
+	q.log()
+	// Scan the row returned by the RETURNING clause into the result
+	err := pgxscan.Get(ctx, q.Pool, q.Result, q.Query, q.Args...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		q.RowsAffected = 0
+		return nil
+	}
+
 	if err != nil {
 		return err
 	}
 
-	defer conn.Release()
-
-	fmt.Printf("[query] %s: %v\n\n", q.Query, q.Args)
-	// Exec does not return any rows
-	err = pgxscan.Get(q.Context, q.Pool, q.Result, q.Query, q.Args...)
-	return err
+	q.RowsAffected = 1
+	return nil
 }
@@ -0,0 +1,26 @@
+package query
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestQueryLog(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"", "[query] SELECT * FROM users WHERE id = $1 [1]\n\n"},
+		{"User.Find", "[query] User.Find: SELECT * FROM users WHERE id = $1 [1]\n\n"},
+	}
+
+	for _, tt := range tests {
+		buf := bytes.Buffer{}
+		q := &Query{Query: "SELECT * FROM users WHERE id = $1", Args: []interface{}{1}, Label: tt.label, Logger: &buf}
+		q.log()
+
+		if buf.String() != tt.want {
+			t.Errorf("log() with label %q = %q, want %q", tt.label, buf.String(), tt.want)
+		}
+	}
+}
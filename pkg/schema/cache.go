@@ -0,0 +1,45 @@
+package schema
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Key for cached table schemas
+type schemaKey struct {
+	Type    reflect.Type
+	Dialect string
+	Options string
+}
+
+// Table schemas keyed by schemaKey.
+// A model's schema is parsed once and reused by all queries.
+var schemaCache sync.Map
+
+// Returns the cached table schema for struct type t or nil if it's not cached
+func cachedSchema(t reflect.Type, dialect string, opts Options) *TableSchema {
+	if tblSchema, ok := schemaCache.Load(schemaKey{t, dialect, opts.key()}); ok {
+		return tblSchema.(*TableSchema)
+	}
+	return nil
+}
+
+// Caches tblSchema for struct type t. If another goroutine cached the schema first,
+// its schema is returned so all callers share the same TableSchema.
+func cacheSchema(t reflect.Type, dialect string, opts Options, tblSchema *TableSchema) *TableSchema {
+	actual, _ := schemaCache.LoadOrStore(schemaKey{t, dialect, opts.key()}, tblSchema)
+	return actual.(*TableSchema)
+}
+
+// ClearSchemaCache removes all cached table schemas and the foreign keys they registered.
+// Schemas are parsed again the next time they are used.
+func ClearSchemaCache() {
+	schemaCache.Range(func(key, _ interface{}) bool {
+		schemaCache.Delete(key)
+		return true
+	})
+
+	ddlMu.Lock()
+	ForeignKeys = make(map[string][]*ForeignKey)
+	ddlMu.Unlock()
+}
@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/abiiranathan/gosqlorm/pkg/models"
+)
+
+// Compares parsing the schema of a model on every call with reading it from the cache,
+// as Create does for each inserted row.
+func BenchmarkGetTableSchema(b *testing.B) {
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ClearSchemaCache()
+			if _, err := GetTableSchema(&models.User{}, "postgres"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		ClearSchemaCache()
+		for i := 0; i < b.N; i++ {
+			if _, err := GetTableSchema(&models.User{}, "postgres"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+)
+
+// Returns the deduplicated table schemas for models in the order of models
+func tableSchemas(driver string, opts Options, models ...interface{}) ([]*TableSchema, error) {
+	tables := []*TableSchema{}
+	for _, model := range models {
+		s, err := GetTableSchemaWithOptions(model, driver, opts)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, s)
+	}
+
+	return uniqueTables(tables), nil
+}
+
+// Returns tables without the tables whose qualified name is already in tables
+func uniqueTables(tables []*TableSchema) []*TableSchema {
+	unique := []*TableSchema{}
+	seen := map[string]bool{}
+
+	for _, s := range tables {
+		if seen[s.QualifiedName()] {
+			continue
+		}
+
+		seen[s.QualifiedName()] = true
+		unique = append(unique, s)
+	}
+
+	return unique
+}
+
+// Sorts tables so that every table comes after the tables its foreign keys reference.
+// Tables without dependencies keep their relative order. Tables in a reference cycle
+// are kept in their original order, their foreign keys are added after all tables anyway.
+func sortTables(tables []*TableSchema) []*TableSchema {
+	byName := map[string]*TableSchema{}
+	for _, t := range tables {
+		byName[t.QualifiedName()] = t
+	}
+	foreignKeys := tableForeignKeys(tables)
+
+	sorted := make([]*TableSchema, 0, len(tables))
+	visited := map[string]bool{}
+
+	var visit func(t *TableSchema)
+	visit = func(t *TableSchema) {
+		if visited[t.QualifiedName()] {
+			return
+		}
+		visited[t.QualifiedName()] = true
+
+		for _, fk := range foreignKeys[t.QualifiedName()] {
+			if parent, ok := byName[fk.ParentTable]; ok {
+				visit(parent)
+			}
+		}
+
+		sorted = append(sorted, t)
+	}
+
+	for _, t := range tables {
+		visit(t)
+	}
+
+	return sorted
+}
+
+// Writes the DDL for all models to w without executing it.
+//
+// Schemas other than public, extensions and enum types are written first, followed by the tables and their
+// indexes in foreign key dependency order and finally the foreign key constraints, in the order AutoMigrate runs them.
+// The output can be committed to version control as a schema file.
+func DumpSchema(w io.Writer, driver string, models ...interface{}) error {
+	tables, err := tableSchemas(driver, Options{}, models...)
+	if err != nil {
+		return err
+	}
+
+	return DumpTables(w, driver, tables...)
+}
+
+// Same as DumpSchema for tables parsed with GetTableSchemaWithOptions
+func DumpTables(w io.Writer, driver string, tables ...*TableSchema) error {
+	statements, err := migrationStatements(driver, tables)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err := fmt.Fprintf(w, "%s\n\n", statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
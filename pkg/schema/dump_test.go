@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/abiiranathan/gosqlorm/pkg/datatypes"
+	"github.com/google/uuid"
+)
+
+type dumpStatus string
+
+func (dumpStatus) EnumValues() []string { return []string{"active", "inactive"} }
+
+type dumpCustomer struct {
+	ID       uuid.UUID     `orm:"primaryKey;default:gen_random_uuid()"`
+	Email    string        `orm:"index"`
+	Status   dumpStatus    `orm:"not null"`
+	Invoices []dumpInvoice `orm:"foreignKey:CustomerID->ID"`
+}
+
+func (dumpCustomer) SchemaName() string { return "sales" }
+
+type dumpInvoice struct {
+	ID         int `orm:"primaryKey;autoIncrement"`
+	CustomerID uuid.UUID
+	Total      datatypes.Money `orm:"not null"`
+}
+
+func (dumpInvoice) SchemaName() string { return "sales" }
+
+func TestDumpSchema(t *testing.T) {
+	buf := bytes.Buffer{}
+
+	// Referenced tables are written first
+	if err := DumpSchema(&buf, "postgres", &dumpInvoice{}, &dumpCustomer{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"CREATE SCHEMA IF NOT EXISTS sales;",
+		"CREATE EXTENSION IF NOT EXISTS pgcrypto;",
+		"CREATE TYPE sales.dump_status AS ENUM ('active', 'inactive');",
+		"CREATE TYPE money_amount AS (amount numeric, currency char(3));",
+		"CREATE TABLE IF NOT EXISTS sales.dump_customers (",
+		"CREATE INDEX IF NOT EXISTS idx_dump_customers_email ON sales.dump_customers (email);",
+		"CREATE TABLE IF NOT EXISTS sales.dump_invoices (",
+		"ALTER TABLE sales.dump_invoices ADD CONSTRAINT",
+	}
+
+	dump := buf.String()
+	offset := 0
+	for _, statement := range want {
+		i := strings.Index(dump[offset:], statement)
+		if i < 0 {
+			t.Fatalf("%q is missing or out of order in:\n%s", statement, dump)
+		}
+		offset += i + len(statement)
+	}
+
+	if n := strings.Count(dump, "CREATE EXTENSION"); n != 1 {
+		t.Errorf("dump has %d CREATE EXTENSION statements, want 1", n)
+	}
+}
+
+func TestDumpSchemaWithoutExtensions(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := DumpSchema(&buf, "sqlite", &dumpCustomer{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buf.String(), "CREATE EXTENSION") || strings.Contains(buf.String(), "CREATE SCHEMA") {
+		t.Errorf("sqlite dump has postgres statements:\n%s", buf.String())
+	}
+}
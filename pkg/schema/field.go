@@ -4,9 +4,23 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+var (
+	// Matches function call defaults such as now() or gen_random_uuid()
+	funcDefaultRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*\(.*\)$`)
+
+	// Matches numeric literals such as 20, -1.5 or 2e10
+	numericDefaultRegex = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+	// SQL keywords that are valid defaults without quoting
+	keywordDefaults = []string{"null", "true", "false", "current_timestamp", "current_date", "current_time", "localtime", "localtimestamp"}
+)
+
 // Field is the data structure that stores data about a single struct field
 type Field struct {
 	Name            string
@@ -44,6 +58,24 @@ func (field *Field) IsForeignKey() bool {
 	return isFk
 }
 
+// Returns true if the field is a relation to another model e.g Profile UserProfile or
+// Tokens []Token with a foreignKey tag. Relations are not columns of the table,
+// their foreign key column is in the table of the related model.
+//
+// Scalar fields with a foreignKey tag e.g UserID int `orm:"foreignKey:users->id"`
+// are columns that reference another table and are not relations.
+func (f *Field) IsRelation() bool {
+	if !f.IsForeignKey() {
+		return false
+	}
+
+	t := f.ReflectObjType.Type
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
 func (f *Field) IsPrimaryKeyAndZero() bool {
 	isPk := false
 
@@ -59,7 +91,7 @@ func (f *Field) IsPrimaryKeyAndZero() bool {
 
 func (f *Field) IsConstraint(tagName string) bool {
 	flag := false
-	for _, t := range []string{"unique", "check", "uniqueIndex", "autoIncrement", "foreignKey", "onDelete", "onUpdate"} {
+	for _, t := range []string{"unique", "check", "uniqueIndex", "index", "autoIncrement", "foreignKey", "onDelete", "onUpdate", "deferrable"} {
 		if tagName == t {
 			flag = true
 			break
@@ -82,8 +114,26 @@ func (f *Field) IsAutoIncrement() bool {
 	return isAuto
 }
 
+// Returns true for autoIncrement and serial columns, which are left out of inserts when zero.
+func (f *Field) IsSerial() bool {
+	if f.IsAutoIncrement() {
+		return true
+	}
+
+	sqlType := strings.ToLower(f.Tags["type"])
+	return sqlType == "serial" || sqlType == "smallserial" || sqlType == "bigserial"
+}
+
+// Returns true for generated:<expr> columns, which are left out of every insert and update.
+func (f *Field) IsComputed() bool {
+	return f.Tags["generated"] != ""
+}
+
 // Checks if a foreign key with constraint constraint_name exists
-// in a global map of foreign keys
+// in a global map of foreign keys.
+//
+// Deprecated: constraint names are only unique per table, the same model parsed
+// in two postgres schemas has the same constraint names. Registration checks the table only.
 func (f *Field) FKExists(constraint_name string) bool {
 	exists := false
 	for _, fksList := range ForeignKeys {
@@ -97,10 +147,104 @@ func (f *Field) FKExists(constraint_name string) bool {
 	return exists
 }
 
+// Returns true if a foreign key named constraintName is registered in ForeignKeys
+// for tableName, the schema qualified name of the table of the foreign key column.
+func foreignKeyExists(tableName, constraintName string) bool {
+	for _, fk := range ForeignKeys[tableName] {
+		if fk.ConstraintName == constraintName {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the field has an explicit null or not null tag
+func (f *Field) HasNullTag() bool {
+	for tagName := range f.Tags {
+		if strings.EqualFold(tagName, "null") || strings.EqualFold(tagName, "not null") {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the column can be inferred to be NOT NULL from its Go type.
+//
+// Pointers, slices, maps and sql.Null* style structs (structs with a Valid bool field)
+// can hold a null value. Primary keys, foreign keys and fields with an explicit
+// null or not null tag are never inferred.
+func (f *Field) IsNotNullInferred() bool {
+	if f.IsPrimaryKey() || f.IsRelation() || f.HasNullTag() {
+		return false
+	}
+
+	t := f.ReflectObjType.Type
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return false
+	case reflect.Struct:
+		if valid, ok := t.FieldByName("Valid"); ok && valid.Type.Kind() == reflect.Bool {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns the sql type of the column from the type tag or the Go type
+func (f *Field) SQLType() string {
+	if f.Tags["type"] != "" {
+		return strings.ToLower(f.withSize(f.Tags["type"]))
+	}
+	return f.withSize(OrmType(f.ReflectObjValue))
+}
+
+// Returns true if the sql type of the column has a length set by the size tag
+func (f *Field) isSized() bool {
+	sqlType := f.Tags["type"]
+	if sqlType == "" {
+		sqlType = OrmType(f.ReflectObjValue)
+	}
+
+	name := typeName(sqlType)
+	return name == "varchar" || name == "char"
+}
+
+// Returns the lower case name of sqlType without its length e.g varchar for VARCHAR(255)
+func typeName(sqlType string) string {
+	name := strings.ToLower(sqlType)
+	if i := strings.Index(name, "("); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// Returns sqlType with the length of the size tag e.g size:100 -> varchar(100).
+// Only varchar and char types have a length, GetTableSchema rejects the size tag on other types.
+func (f *Field) withSize(sqlType string) string {
+	size := f.Tags["size"]
+	if size == "" {
+		return sqlType
+	}
+
+	name := typeName(sqlType)
+	if name != "varchar" && name != "char" {
+		return sqlType
+	}
+
+	return fmt.Sprintf("%s(%s)", sqlType[:len(name)], size)
+}
+
+// Returns true if the column type can be indexed with a GIN index
+func (f *Field) SupportsGIN() bool {
+	sqlType := f.SQLType()
+	return strings.Contains(sqlType, "json") || strings.HasSuffix(sqlType, "[]") || sqlType == "tsvector"
+}
+
 // Write field tags representing constraints to the underlying field bytes.Buffer
 func (f *Field) WriteFieldConstraints(k, v string) {
-	if k == "unique" {
-		f.Table.UniqueFields = append(f.Table.UniqueFields, f)
+	if k == "unique" || k == "uniqueIndex" || k == "foreignKey" {
+		// Parsed by GetTableSchema, see TableSchema.parseConstraints
 	} else if k == "autoIncrement" {
 		f.buf.WriteString(" ")
 		if f.dialect == "postgres" {
@@ -110,50 +254,55 @@ func (f *Field) WriteFieldConstraints(k, v string) {
 		} else if f.dialect == "sqlite" {
 			f.buf.WriteString("AUTOINCREMENT")
 		}
-	} else if k == "uniqueIndex" {
-		f.Table.CompositeIndexes[v] = append(f.Table.CompositeIndexes[v], f)
-	} else if k == "foreignKey" {
-		// v should of the form fk->id
-		fks := strings.Split(v, "->")
+	} else if k == "index" {
+		// Indexes are created after the table, see TableSchema.Indexes
+	} else if k == "check" {
+		f.buf.WriteString(fmt.Sprintf(" CHECK (%s)", v))
+	}
+}
 
-		if len(fks) != 2 {
-			panic(fmt.Sprintf("Invalid foreign key definition: %s", v))
-		}
+// Writes the DEFAULT clause for the tag value v to the field buffer.
+//
+// Function calls like now(), SQL keywords and already quoted literals are
+// written verbatim. Booleans are normalized to true/false, numbers are
+// written as is and any other value is quoted as a string literal.
+func (f *Field) WriteDefault(v string) {
+	f.buf.WriteString(" DEFAULT ")
+	f.buf.WriteString(f.defaultValue(v))
+}
 
-		// Get struct type of the foreign key field
-		fkStructType := f.ReflectObjValue.Interface()
-		// Get the foreign key field
-		// append to map
-		constraint_name := fmt.Sprintf("%s_%s_fkey", SnakeCase(f.Table.TableName), SnakeCase(f.Name))
-		if f.FKExists(constraint_name) {
-			return
-		}
+// Returns the SQL literal for the default value v
+func (f *Field) defaultValue(v string) string {
+	if funcDefaultRegex.MatchString(v) || strings.HasPrefix(v, "'") {
+		return v
+	}
+
+	kind := f.ReflectObjType.Type.Kind()
+	if kind == reflect.Pointer {
+		kind = f.ReflectObjType.Type.Elem().Kind()
+	}
 
-		TableName := GetTableName(fkStructType)
-		fk := &ForeignKey{
-			ConstraintName: constraint_name,
-			OnDelete:       "",
-			OnUpdate:       "",
-			FK:             fks[0],
-			ParentPkColumn: fks[1],
-			TableName:      TableName,
-			ParentTable:    SnakeCase(f.Table.TableName),
+	if kind == reflect.Bool {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return strconv.FormatBool(b)
 		}
+	}
 
-		ForeignKeys[TableName] = append(ForeignKeys[TableName], fk)
+	if kind != reflect.String {
+		if numericDefaultRegex.MatchString(v) {
+			return v
+		}
 
-		// Get onDelete and onUpdate Constraints
-		for k, v := range f.Tags {
-			if k == "onDelete" {
-				fk.OnDelete = fmt.Sprintf(" ON DELETE %s", v)
-			} else if k == "onUpdate" {
-				fk.OnUpdate = fmt.Sprintf(" ON UPDATE %s", v)
+		for _, keyword := range keywordDefaults {
+			if strings.EqualFold(v, keyword) {
+				return v
 			}
 		}
-
-	} else if k == "check" {
-		f.buf.WriteString(fmt.Sprintf(" CHECK (%s)", v))
+	} else if strings.EqualFold(v, "null") {
+		return v
 	}
+
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
 }
 
 // Writes column name and type to the buffer
@@ -166,6 +315,7 @@ func (f *Field) PrintType(sqlType string, dialect string) {
 			f.buf.WriteString("SERIAL")
 		} else {
 			f.buf.WriteString(strings.ToUpper(sqlType))
+			f.printUnsigned(dialect)
 		}
 		return
 	}
@@ -175,18 +325,63 @@ func (f *Field) PrintType(sqlType string, dialect string) {
 		return
 	}
 
+	// mysql and sqlite store binary data as BLOB
+	if (dialect == "mysql" || dialect == "sqlite") && sqlType == "bytea" {
+		f.buf.WriteString("BLOB")
+		return
+	}
+
+	// Quoted identifiers are case sensitive
+	if strings.Contains(sqlType, `"`) {
+		f.buf.WriteString(sqlType)
+		return
+	}
+
 	f.buf.WriteString(strings.ToUpper(sqlType))
+	f.printUnsigned(dialect)
+}
+
+// Writes the UNSIGNED modifier of mysql unsigned integer columns
+func (f *Field) printUnsigned(dialect string) {
+	if dialect == "mysql" && f.IsUnsigned() {
+		f.buf.WriteString(" UNSIGNED")
+	}
+}
+
+// Returns the quoted collation name e.g und-x-icu -> "und-x-icu".
+// Quotes around name are removed before it's quoted, mysql names are quoted with backticks.
+func quoteCollation(name, dialect string) string {
+	return dialectFor(dialect).QuoteIdent(strings.Trim(name, "\"`'"))
+}
+
+// Returns true if the field is an unsigned integer
+func (f *Field) IsUnsigned() bool {
+	switch f.ReflectObjType.Type.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
 }
 
-// Print all field tags to the field buffer
+// Print all field tags to the field buffer.
+// Tags are printed in sorted order so the generated sql is stable.
 func (f *Field) PrintTags() {
-	for k, v := range f.Tags {
-		if k == "type" || k == "primaryKey" {
+	keys := make([]string, 0, len(f.Tags))
+	for k := range f.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := f.Tags[k]
+		if k == "type" || k == "primaryKey" || k == "size" || k == "collate" || k == "generated" {
 			continue
 		}
 
 		if f.IsConstraint(k) {
 			f.WriteFieldConstraints(k, v)
+		} else if k == "default" {
+			f.WriteDefault(v)
 		} else {
 			if v == "" {
 				// No tag data, just print the key
@@ -207,21 +402,46 @@ func (f *Field) PrintTags() {
 //
 // e.g : name varchar(200) not null unique
 func (f *Field) String() string {
+	// Relations e.g Tokens []Token are not columns, only their foreign keys are registered
+	if f.IsRelation() {
+		f.PrintTags()
+		return f.buf.String()
+	}
+
 	if f.Tags["type"] != "" {
-		f.PrintType(f.Tags["type"], f.dialect)
+		f.PrintType(f.withSize(f.Tags["type"]), f.dialect)
 	} else {
 		sqlType := OrmType(f.ReflectObjValue)
 
+		// Enum types are created in the schema of the table
+		if _, _, ok := enumType(f.ReflectObjValue.Type()); ok {
+			sqlType = QualifyName(f.Table.Schema, sqlType)
+		}
+
 		if sqlType != "" {
-			f.PrintType(sqlType, f.dialect)
+			f.PrintType(f.withSize(sqlType), f.dialect)
 		}
 	}
 
-	if f.IsPrimaryKey() {
-		f.Table.PrimaryKey = f
+	// The collation comes right after the type, before the constraints
+	if collation := f.Tags["collate"]; collation != "" {
+		f.buf.WriteString(" COLLATE " + quoteCollation(collation, f.dialect))
+	}
+
+	if f.IsComputed() {
+		f.buf.WriteString(fmt.Sprintf(" GENERATED ALWAYS AS (%s) STORED", f.Tags["generated"]))
 	}
 
 	f.PrintTags()
 
+	// Postgres and sqlite have no unsigned types, generated values are never negative
+	if f.dialect != "mysql" && f.IsUnsigned() && !f.IsSerial() {
+		f.buf.WriteString(fmt.Sprintf(" CHECK (%s >= 0)", SnakeCase(f.Name)))
+	}
+
+	if f.Table.opts.InferNotNull && f.IsNotNullInferred() {
+		f.buf.WriteString(" NOT NULL")
+	}
+
 	return f.buf.String()
 }
@@ -0,0 +1,201 @@
+package schema
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gosqlorm/pkg/datatypes"
+	"github.com/lib/pq"
+)
+
+// Returns the unparsed Field of the struct field name of model
+func structField(t *testing.T, model interface{}, name string) *Field {
+	t.Helper()
+
+	sf, ok := reflect.TypeOf(model).FieldByName(name)
+	if !ok {
+		t.Fatalf("%T has no field %s", model, name)
+	}
+	return &Field{Name: name, ReflectObjType: &sf}
+}
+
+type defaultsModel struct {
+	Name      string
+	Nickname  *string
+	Age       int
+	Score     float64
+	Active    bool
+	CreatedAt time.Time
+}
+
+func TestDefaultValue(t *testing.T) {
+	tests := []struct {
+		field string
+		value string
+		want  string
+	}{
+		// Function calls
+		{"Name", "gen_random_uuid()", "gen_random_uuid()"},
+		{"CreatedAt", "now()", "now()"},
+		{"Age", "floor(random() * 10)", "floor(random() * 10)"},
+
+		// Keywords are only written verbatim for non string columns
+		{"CreatedAt", "current_timestamp", "current_timestamp"},
+		{"CreatedAt", "CURRENT_DATE", "CURRENT_DATE"},
+		{"Age", "null", "null"},
+		{"Name", "current_timestamp", "'current_timestamp'"},
+		{"Name", "true", "'true'"},
+
+		// Bools
+		{"Active", "true", "true"},
+		{"Active", "TRUE", "true"},
+		{"Active", "1", "true"},
+		{"Active", "f", "false"},
+		{"Active", "null", "null"},
+
+		// Numerics
+		{"Age", "20", "20"},
+		{"Age", "-1", "-1"},
+		{"Score", "1.5", "1.5"},
+		{"Score", ".5", ".5"},
+		{"Score", "2e10", "2e10"},
+		{"Name", "20", "'20'"},
+
+		// Strings
+		{"Name", "bob", "'bob'"},
+		{"Name", "'bob'", "'bob'"},
+		{"Name", "O'Brien", "'O''Brien'"},
+		{"Name", "12:00", "'12:00'"},
+		{"Nickname", "bob", "'bob'"},
+
+		// Null on string fields
+		{"Name", "null", "null"},
+		{"Name", "NULL", "NULL"},
+		{"Nickname", "null", "null"},
+	}
+
+	for _, tt := range tests {
+		field := structField(t, defaultsModel{}, tt.field)
+		if got := field.defaultValue(tt.value); got != tt.want {
+			t.Errorf("defaultValue(%s, %q) = %s, want %s", tt.field, tt.value, got, tt.want)
+		}
+	}
+}
+
+type inferredModel struct {
+	ID       int `orm:"primaryKey;autoIncrement"`
+	Name     string
+	Age      int
+	Nickname *string
+	Tags     pq.StringArray
+	Bio      sql.NullString
+	Email    datatypes.NullString
+	Note     string `orm:"null"`
+	Code     string `orm:"not null"`
+}
+
+func TestIsNotNullInferred(t *testing.T) {
+	tblSchema, err := GetTableSchema(&inferredModel{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"Name": true, "Age": true}
+	for _, field := range tblSchema.Fields {
+		if got := field.IsNotNullInferred(); got != want[field.Name] {
+			t.Errorf("%s.IsNotNullInferred() = %t, want %t", field.Name, got, want[field.Name])
+		}
+	}
+
+	if ddl := tblSchema.String("postgres"); strings.Contains(ddl, "NOT NULL") {
+		t.Errorf("NOT NULL is inferred without InferNotNull:\n%s", ddl)
+	}
+
+	tblSchema, err = GetTableSchemaWithOptions(&inferredModel{}, "postgres", Options{InferNotNull: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ddl := tblSchema.String("postgres")
+	for _, column := range []string{"name VARCHAR(255) NOT NULL,", "age INTEGER NOT NULL,"} {
+		if !strings.Contains(ddl, column) {
+			t.Errorf("table has no column %s:\n%s", column, ddl)
+		}
+	}
+
+	if n := strings.Count(ddl, "NOT NULL"); n != 2 {
+		t.Errorf("table has %d inferred NOT NULL columns, want 2:\n%s", n, ddl)
+	}
+}
+
+type columnModel struct {
+	ID      int `orm:"primaryKey;autoIncrement"`
+	Data    []byte
+	Count   uint
+	Total   uint64 `orm:"not null"`
+	Small   uint8
+	Code    string `orm:"size:3"`
+	Fixed   string `orm:"type:char;size:2"`
+	Name    string `orm:"collate:C"`
+	Title   string `orm:"size:100;collate:en_US"`
+	Doubled int    `orm:"generated:count * 2"`
+}
+
+func TestFieldString(t *testing.T) {
+	tests := []struct {
+		dialect string
+		field   string
+		want    string
+	}{
+		{"postgres", "Data", "  data BYTEA"},
+		{"mysql", "Data", "  data BLOB"},
+		{"sqlite", "Data", "  data BLOB"},
+
+		// Unsigned integers are checked to be non-negative unless the dialect has UNSIGNED
+		{"postgres", "Count", "  count BIGINT CHECK (count >= 0)"},
+		{"postgres", "Total", "  total BIGINT not null CHECK (total >= 0)"},
+		{"postgres", "Small", "  small INTEGER CHECK (small >= 0)"},
+		{"mysql", "Count", "  count BIGINT UNSIGNED"},
+		{"mysql", "Total", "  total BIGINT UNSIGNED not null"},
+		{"mysql", "Small", "  small INTEGER UNSIGNED"},
+		{"sqlite", "Count", "  count BIGINT CHECK (count >= 0)"},
+
+		// Sizes
+		{"postgres", "Code", "  code VARCHAR(3)"},
+		{"postgres", "Fixed", "  fixed CHAR(2)"},
+		{"mysql", "Code", "  code VARCHAR(3)"},
+		{"sqlite", "Fixed", "  fixed CHAR(2)"},
+
+		// Collations are quoted for the dialect
+		{"postgres", "Name", `  name VARCHAR(255) COLLATE "C"`},
+		{"postgres", "Title", `  title VARCHAR(100) COLLATE "en_US"`},
+		{"mysql", "Name", "  name VARCHAR(255) COLLATE `C`"},
+		{"sqlite", "Title", `  title VARCHAR(100) COLLATE "en_US"`},
+
+		// Generated columns
+		{"postgres", "Doubled", "  doubled INTEGER GENERATED ALWAYS AS (count * 2) STORED"},
+		{"mysql", "Doubled", "  doubled INTEGER GENERATED ALWAYS AS (count * 2) STORED"},
+		{"sqlite", "Doubled", "  doubled INTEGER GENERATED ALWAYS AS (count * 2) STORED"},
+	}
+
+	for _, tt := range tests {
+		tblSchema, err := GetTableSchema(&columnModel{}, tt.dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := ""
+		for _, field := range tblSchema.Fields {
+			if field.Name == tt.field {
+				got = field.String()
+			}
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: %s.String() = %q, want %q", tt.dialect, tt.field, got, tt.want)
+		}
+	}
+}
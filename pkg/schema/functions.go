@@ -2,10 +2,12 @@ package schema
 
 import (
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/abiiranathan/gosqlorm/pkg/datatypes"
+	"github.com/abiiranathan/gosqlorm/pkg/query"
 	"github.com/google/uuid"
 	"github.com/iancoleman/strcase"
 	"github.com/lib/pq"
@@ -36,9 +38,13 @@ func IsStructPointer(v interface{}) bool {
 	return IsPointer(v) && IsStruct(reflect.ValueOf(v).Elem().Interface())
 }
 
-// Creates a new struct pointer from a *[]*Model
+// Creates a new struct pointer from a *[]*Model or *[]Model
 func NewStructPointer(model interface{}) any {
-	return reflect.New(reflect.TypeOf(model).Elem().Elem().Elem()).Interface()
+	t := reflect.TypeOf(model).Elem().Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return reflect.New(t).Interface()
 }
 
 // v must be a of the form *[]*Model{}
@@ -49,48 +55,137 @@ func IsPointerToArrayOfStructPointer(v interface{}) bool {
 		reflect.TypeOf(v).Elem().Elem().Elem().Kind() == reflect.Struct
 }
 
+// v must be of the form *[]*Model{} or *[]Model{}
+func IsPointerToSliceOfStructs(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
+		return false
+	}
+
+	elem := t.Elem().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+// Implemented by models that define their own table name
+type tabler interface {
+	TableName() string
+}
+
+// Returns the table name for model v with the default Options.
+//
+// v may be a struct, a pointer to a struct or a slice of either.
+// If the model has a TableName() method, its result is used. Otherwise
+// the snake_case type name is pluralized.
 func GetTableName(v interface{}) string {
-	for i := 0; i < reflect.TypeOf(v).NumMethod(); i++ {
-		method := reflect.TypeOf(v).Method(i)
+	return Options{}.tableName(v)
+}
 
-		if method.PkgPath != "" {
-			continue
-		}
+// Returns the table name for model v.
+// The snake_case type name is pluralized unless opts.SingularTableNames is set.
+// opts.TablePrefix is prepended to it and to names returned by TableName().
+func (opts Options) tableName(v interface{}) string {
+	return opts.prefixTableName(opts.modelTableName(v))
+}
 
-		if method.Name == "TableName" {
-			return method.Func.Call([]reflect.Value{reflect.ValueOf(v)})[0].String()
-		}
+// Returns the table name of model v without the table prefix
+func (opts Options) modelTableName(v interface{}) string {
+	// Calling TableName on a nil pointer would panic for value receivers
+	if t, ok := v.(tabler); ok && !(IsPointer(v) && reflect.ValueOf(v).IsNil()) {
+		return t.TableName()
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
 
+	// TableName may be defined on the value or the pointer receiver
+	if t, ok := reflect.New(t).Interface().(tabler); ok {
+		return t.TableName()
 	}
 
-	tblName := SnakeCase(reflect.TypeOf(v).Name())
-	return pleuralize(tblName)
+	return opts.tableNameFor(SnakeCase(t.Name()))
 }
 
-// if s ends with y -> ies
-// s ends with s -> do not modify
-// otherwise add s
-func pleuralize(s string) string {
-	if strings.HasSuffix(s, "y") {
-		return s[0:len(s)-1] + "ies"
+// Returns the table name for the singular snake_case model name
+func (opts Options) tableNameFor(name string) string {
+	if opts.SingularTableNames {
+		return name
 	}
-	if strings.HasSuffix(s, "s") {
-		return s
+
+	return opts.pluralize(name)
+}
+
+// Prepends opts.TablePrefix to the table name.
+// For schema qualified names e.g app.users, the prefix is added to the table e.g app.tenant_users
+func (opts Options) prefixTableName(name string) string {
+	if opts.TablePrefix == "" {
+		return name
 	}
-	return s + "s"
+
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i+1] + opts.TablePrefix + name[i+1:]
+	}
+
+	return opts.TablePrefix + name
+}
+
+// Implemented by custom types that define their sql type
+// e.g func (Money) SQLType() string { return "numeric(12,2)" }
+type sqlTyper interface {
+	SQLType() string
 }
 
-// OrmType uses reflection to guess corresponding database type
+// Implemented by column types whose sql type must be created before the table
+// e.g the composite type of datatypes.Money
+type typeCreator interface {
+	CreateTypeSQL() string
+}
+
+// Returns the sql type of t if t or *t implements sqlTyper
+func customSQLType(t reflect.Type) (string, bool) {
+	if t.Kind() == reflect.Pointer {
+		return "", false
+	}
+
+	if s, ok := reflect.New(t).Interface().(sqlTyper); ok {
+		return s.SQLType(), true
+	}
+
+	return "", false
+}
+
+// OrmType uses reflection to guess corresponding database type.
+// Types with a SQLType() method use the type it returns.
 func OrmType(v *reflect.Value) string {
 	var sqlType string
 
+	// Enums use the postgres type created for them, also when they are nullable
+	if t, _, ok := enumType(v.Type()); ok {
+		return EnumTypeName(t)
+	}
+
+	if sqlType, ok := customSQLType(v.Type()); ok {
+		return sqlType
+	}
+
 	switch v.Kind() {
+	case reflect.Pointer:
+		// Pointers are nullable columns of the type they point to
+		elem := reflect.New(v.Type().Elem()).Elem()
+		sqlType = OrmType(&elem)
 	case reflect.String:
 		sqlType = "varchar(255)"
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		sqlType = "integer"
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	case reflect.Uint8, reflect.Uint16:
 		sqlType = "integer"
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		// Values above the max int32 do not fit in an integer
+		sqlType = "bigint"
 	case reflect.Float32, reflect.Float64:
 		sqlType = "real"
 	case reflect.Bool:
@@ -101,28 +196,37 @@ func OrmType(v *reflect.Value) string {
 			sqlType = "uuid"
 		}
 	case reflect.Slice:
-		if _, ok := v.Interface().(pq.StringArray); ok {
-			sqlType = "text[]"
-		} else if _, ok := v.Interface().(pq.Int64Array); ok {
-			sqlType = "integer[]"
-		} else if _, ok := v.Interface().(pq.Float64Array); ok {
-			sqlType = "real[]"
-		} else if _, ok := v.Interface().(pq.BoolArray); ok {
-			sqlType = "boolean[]"
-		} else if _, ok := v.Interface().(pq.ByteaArray); ok {
+		// pq.StringArray, pq.Int64Array, pq.Float64Array, pq.BoolArray
+		// and native slices like []int32 are mapped by their element type.
+		if _, ok := v.Interface().(pq.ByteaArray); ok {
 			sqlType = "bytea[]"
-		} else if _, ok := v.Interface().(pq.StringArray); ok {
-			sqlType = "text[]"
+		} else if v.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte is binary data, see Field.PrintType for mysql and sqlite
+			sqlType = "bytea"
 		} else {
-			sqlType = "text[]"
+			sqlType = arrayType(v.Type().Elem())
 		}
 	case reflect.TypeOf(datatypes.JSON{}).Kind():
 		sqlType = "json"
 	case reflect.Struct:
-		// If it's a time.Time, we'll assume it's a timestamp
-		if v.Type() == reflect.TypeOf(datatypes.Date{}) {
+		if _, ok := v.Interface().(pq.GenericArray); ok {
+			// The schema is parsed from the zero value, so the element type of A is unknown.
+			// Arrays of other types need a type tag e.g `orm:"type:integer[]"`
+			sqlType = "text[]"
+		} else if v.Type() == reflect.TypeOf(datatypes.Date{}) {
 			sqlType = "date"
+		} else if v.Type() == reflect.TypeOf(datatypes.Time{}) {
+			sqlType = "time"
+		} else if v.Type() == reflect.TypeOf(datatypes.NullString{}) {
+			sqlType = "varchar(255)"
+		} else if v.Type() == reflect.TypeOf(datatypes.NullInt64{}) {
+			sqlType = "bigint"
+		} else if v.Type() == reflect.TypeOf(datatypes.NullBool{}) {
+			sqlType = "boolean"
+		} else if v.Type() == reflect.TypeOf(datatypes.NullFloat64{}) {
+			sqlType = "double precision"
 		} else if v.Type() == reflect.TypeOf(time.Time{}) {
+			// If it's a time.Time, we'll assume it's a timestamp
 			sqlType = "timestamptz"
 		}
 	}
@@ -130,9 +234,153 @@ func OrmType(v *reflect.Value) string {
 	return sqlType
 }
 
+// Returns the name of the postgres enum type for the Go type t
+func EnumTypeName(t reflect.Type) string {
+	return SnakeCase(t.Name())
+}
+
+// Returns the enum type of t and its zero value if t or the type it points to is an enum.
+// Pointers are dereferenced, so the values of a nullable enum e.g *Status
+// are read from a Status and not from a nil pointer.
+func enumType(t reflect.Type) (reflect.Type, datatypes.Enum, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	enum, ok := reflect.New(t).Elem().Interface().(datatypes.Enum)
+	return t, enum, ok
+}
+
+// Returns the sql array type for slices with elements of type elem
+// e.g []int32 -> integer[], []time.Time -> timestamptz[].
+// Strings and elements of unknown type are mapped to text[].
+//
+// Native slices are encoded and scanned as postgres arrays by pgx,
+// so []string, []int64, []float64 and []bool need no pq.*Array wrapper.
+func arrayType(elem reflect.Type) string {
+	// Byte slices are not mapped to arrays of integers
+	if elem.Kind() == reflect.String || elem.Kind() == reflect.Uint8 {
+		return "text[]"
+	}
+
+	// Unlike integer and real columns of int64 and float64 fields,
+	// the arrays hold the full range and precision so the values round trip.
+	switch elem {
+	case reflect.TypeOf(int64(0)):
+		return "bigint[]"
+	case reflect.TypeOf(float64(0)):
+		return "double precision[]"
+	}
+
+	zero := reflect.New(elem).Elem()
+	elemType := OrmType(&zero)
+	if elemType == "" || strings.HasSuffix(elemType, "[]") {
+		return "text[]"
+	}
+
+	return elemType + "[]"
+}
+
 // Initializes a pointer to the underlying model struct
 // model must be a struct pointer
 // e.g model := &Model{}
 func GetType(model any) any {
 	return reflect.New(reflect.TypeOf(model).Elem()).Interface()
 }
+
+// Implemented by models that live in a postgres schema other than Options.Schema
+type schemaNamer interface {
+	SchemaName() string
+}
+
+// Returns the postgres schema of model v with the default Options.
+// If the model has a SchemaName() method, its result is used, otherwise public.
+func GetSchemaName(v interface{}) string {
+	return Options{}.schemaName(v)
+}
+
+// Returns the postgres schema of model v.
+// If the model has a SchemaName() method, its result is used, otherwise opts.Schema or public.
+func (opts Options) schemaName(v interface{}) string {
+	if s, ok := v.(schemaNamer); ok && !(IsPointer(v) && reflect.ValueOf(v).IsNil()) {
+		return s.SchemaName()
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	if s, ok := reflect.New(t).Interface().(schemaNamer); ok {
+		return s.SchemaName()
+	}
+
+	if opts.Schema != "" {
+		return opts.Schema
+	}
+
+	return DefaultSchema
+}
+
+// Returns the schema qualified table name of model v e.g public.users with the default Options
+func GetQualifiedTableName(v interface{}) string {
+	return Options{}.qualifiedTableName(v)
+}
+
+// Returns the schema qualified table name of model v e.g public.users
+func (opts Options) qualifiedTableName(v interface{}) string {
+	return QualifyName(opts.schemaName(v), opts.tableName(v))
+}
+
+// Returns the quoted postgres schema qualified name e.g public.users.
+// Names that are already qualified e.g returned by TableName() are not modified.
+func QualifyName(schemaName, name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+
+	if schemaName == "" {
+		return QuoteIdentifier(name, "postgres")
+	}
+
+	return QuoteIdentifier(schemaName, "postgres") + "." + QuoteIdentifier(name, "postgres")
+}
+
+// Returns name without its schema e.g users for app.users
+func unqualifiedName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// Matches identifiers that can be used without quotes
+var plainIdentifierRegex = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// Reserved words that must be quoted when used as table or schema names
+var reservedWords = map[string]bool{
+	"all": true, "and": true, "any": true, "as": true, "asc": true, "case": true, "check": true,
+	"column": true, "constraint": true, "create": true, "default": true, "desc": true,
+	"distinct": true, "do": true, "else": true, "end": true, "except": true, "false": true,
+	"for": true, "foreign": true, "from": true, "grant": true, "group": true, "having": true,
+	"in": true, "intersect": true, "into": true, "limit": true, "not": true, "null": true,
+	"offset": true, "on": true, "or": true, "order": true, "primary": true, "references": true,
+	"select": true, "table": true, "then": true, "to": true, "true": true, "union": true,
+	"unique": true, "user": true, "using": true, "when": true, "where": true, "with": true,
+}
+
+// Returns name quoted with the identifier quotes of dialect e.g "order" or `order`
+// if it's a reserved word or not a lower case identifier.
+func QuoteIdentifier(name, dialect string) string {
+	if plainIdentifierRegex.MatchString(name) && !reservedWords[name] {
+		return name
+	}
+
+	return dialectFor(dialect).QuoteIdent(name)
+}
+
+// Returns the query dialect of the driver name. Unknown drivers use the postgres dialect.
+func dialectFor(driver string) query.Dialect {
+	d, err := query.DialectFor(driver)
+	if err != nil {
+		return query.Postgres
+	}
+	return d
+}
@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gosqlorm/pkg/datatypes"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Returns the OrmType of value v
+func ormType(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	return OrmType(&rv)
+}
+
+func TestOrmType(t *testing.T) {
+	name := "bob"
+
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"", "varchar(255)"},
+		{&name, "varchar(255)"},
+		{1, "integer"},
+		{uint8(1), "integer"},
+		{uint(1), "bigint"},
+		{uint64(1), "bigint"},
+		{1.5, "real"},
+		{true, "boolean"},
+		{time.Time{}, "timestamptz"},
+		{uuid.UUID{}, "uuid"},
+		{[]byte{}, "bytea"},
+		{datatypes.Date{}, "date"},
+		{datatypes.Time{}, "time"},
+		{datatypes.JSON{}, "json"},
+		{datatypes.NullString{}, "varchar(255)"},
+		{datatypes.NullInt64{}, "bigint"},
+		{datatypes.NullBool{}, "boolean"},
+		{datatypes.NullFloat64{}, "double precision"},
+		{pq.StringArray{}, "text[]"},
+		{pq.Int64Array{}, "bigint[]"},
+		{pq.Int32Array{}, "integer[]"},
+		{pq.Float64Array{}, "double precision[]"},
+		{pq.Float32Array{}, "real[]"},
+		{pq.BoolArray{}, "boolean[]"},
+		{pq.ByteaArray{}, "bytea[]"},
+		{pq.GenericArray{A: []int{}}, "text[]"},
+	}
+
+	for _, tt := range tests {
+		if got := ormType(tt.value); got != tt.want {
+			t.Errorf("OrmType(%T) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestOrmTypeNativeSlices(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{[]string{}, "text[]"},
+		{[]int{}, "integer[]"},
+		{[]int32{}, "integer[]"},
+		{[]int64{}, "bigint[]"},
+		{[]float32{}, "real[]"},
+		{[]float64{}, "double precision[]"},
+		{[]bool{}, "boolean[]"},
+		{[]time.Time{}, "timestamptz[]"},
+		{[]uuid.UUID{}, "uuid[]"},
+		{[][]int{}, "text[]"},
+	}
+
+	for _, tt := range tests {
+		if got := ormType(tt.value); got != tt.want {
+			t.Errorf("OrmType(%T) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+type priceAmount int64
+
+func (priceAmount) SQLType() string { return "numeric(12,2)" }
+
+type geoPoint struct {
+	X, Y float64
+}
+
+func (*geoPoint) SQLType() string { return "point" }
+
+func TestOrmTypeOfSQLTypers(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{priceAmount(1), "numeric(12,2)"},
+		{geoPoint{}, "point"},
+		{datatypes.Date{}, "date"},
+		{datatypes.Time{}, "time"},
+	}
+
+	for _, tt := range tests {
+		if got := ormType(tt.value); got != tt.want {
+			t.Errorf("OrmType(%T) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
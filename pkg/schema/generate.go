@@ -0,0 +1,380 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// A column read from information_schema.columns
+type ColumnInfo struct {
+	TableName              string
+	ColumnName             string
+	UdtName                string
+	IsNullable             string
+	ColumnDefault          string
+	CharacterMaximumLength int
+	IsPrimaryKey           bool
+}
+
+// A foreign key read from information_schema
+type ForeignKeyInfo struct {
+	TableName         string
+	ColumnName        string
+	ForeignTableName  string
+	ForeignColumnName string
+	DeleteRule        string
+	UpdateRule        string
+	Deferrable        bool
+}
+
+// Go type for a postgres type and the sql type OrmType generates for it
+type goType struct {
+	Name    string
+	OrmType string
+	Import  string
+}
+
+// Go types for postgres udt names
+var goTypes = map[string]goType{
+	"int2":        {"int16", "integer", ""},
+	"int4":        {"int", "integer", ""},
+	"int8":        {"int64", "integer", ""},
+	"float4":      {"float32", "real", ""},
+	"float8":      {"float64", "real", ""},
+	"numeric":     {"float64", "real", ""},
+	"bool":        {"bool", "boolean", ""},
+	"varchar":     {"string", "varchar(255)", ""},
+	"text":        {"string", "varchar(255)", ""},
+	"bpchar":      {"string", "varchar(255)", ""},
+	"uuid":        {"uuid.UUID", "uuid", "github.com/google/uuid"},
+	"date":        {"datatypes.Date", "date", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"time":        {"datatypes.Time", "time", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"timestamp":   {"datatypes.LocalTimestamp", "timestamp", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"timestamptz": {"time.Time", "timestamptz", "time"},
+	"json":        {"datatypes.JSON", "json", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"jsonb":       {"datatypes.JSON", "json", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"bytea":       {"[]byte", "bytea", ""},
+	"tsvector":    {"datatypes.TSVector", "tsvector", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"_text":       {"[]string", "text[]", ""},
+	"_varchar":    {"[]string", "text[]", ""},
+	"_int4":       {"[]int32", "integer[]", ""},
+	"_int8":       {"[]int64", "bigint[]", ""},
+	"_float4":     {"[]float32", "real[]", ""},
+	"_float8":     {"[]float64", "double precision[]", ""},
+	"_bool":       {"[]bool", "boolean[]", ""},
+}
+
+// Nullable Go types for postgres udt names.
+// Other nullable columns use a pointer to their Go type.
+var nullGoTypes = map[string]goType{
+	"int2":    {"datatypes.NullInt64", "bigint", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"int4":    {"datatypes.NullInt64", "bigint", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"int8":    {"datatypes.NullInt64", "bigint", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"float4":  {"datatypes.NullFloat64", "double precision", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"float8":  {"datatypes.NullFloat64", "double precision", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"numeric": {"datatypes.NullFloat64", "double precision", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"bool":    {"datatypes.NullBool", "boolean", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"varchar": {"datatypes.NullString", "varchar(255)", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"text":    {"datatypes.NullString", "varchar(255)", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+	"bpchar":  {"datatypes.NullString", "varchar(255)", "github.com/abiiranathan/gosqlorm/pkg/datatypes"},
+}
+
+// Names of postgres udt types as used in column definitions
+var sqlTypeNames = map[string]string{
+	"int2":     "smallint",
+	"int4":     "integer",
+	"int8":     "bigint",
+	"float4":   "real",
+	"float8":   "double precision",
+	"bool":     "boolean",
+	"bpchar":   "char",
+	"_text":    "text[]",
+	"_varchar": "varchar[]",
+	"_int4":    "integer[]",
+	"_int8":    "bigint[]",
+	"_float4":  "real[]",
+	"_float8":  "double precision[]",
+	"_bool":    "boolean[]",
+}
+
+// Words written in upper case in Go names
+var initialisms = map[string]bool{
+	"id":   true,
+	"uuid": true,
+	"url":  true,
+	"uri":  true,
+	"api":  true,
+	"ip":   true,
+	"json": true,
+	"html": true,
+	"http": true,
+	"sql":  true,
+}
+
+// Reads the tables of schemaName from information_schema and returns
+// the source of a Go file with a model struct for each table.
+//
+// Column types are mapped back to Go types and datatypes. Primary keys,
+// not null columns, serial columns and foreign keys are written as orm tags,
+// so AutoMigrate on the generated models recreates the schema.
+func GenerateModels(ctx context.Context, pool *pgxpool.Pool, schemaName string) (string, error) {
+	return GenerateModelsWithOptions(ctx, pool, schemaName, Options{})
+}
+
+// Same as GenerateModels but model and table names are resolved with opts
+// e.g the table prefix is removed from the model names.
+func GenerateModelsWithOptions(ctx context.Context, pool *pgxpool.Pool, schemaName string, opts Options) (string, error) {
+	columns, foreignKeys, err := inspectTables(ctx, pool, schemaName)
+	if err != nil {
+		return "", err
+	}
+
+	return ModelsSourceWithOptions("models", columns, foreignKeys, opts)
+}
+
+// Reads the columns and foreign keys of all tables in schemaName
+func inspectTables(ctx context.Context, pool *pgxpool.Pool, schemaName string) ([]*ColumnInfo, []*ForeignKeyInfo, error) {
+	columns := []*ColumnInfo{}
+	err := pgxscan.Select(ctx, pool, &columns, `SELECT c.table_name, c.column_name, c.udt_name, c.is_nullable,
+		COALESCE(c.column_default, '') AS column_default,
+		COALESCE(c.character_maximum_length, 0) AS character_maximum_length,
+		EXISTS (
+			SELECT 1 FROM information_schema.table_constraints tc
+			JOIN information_schema.key_column_usage kcu
+				ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema
+			WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema
+				AND kcu.table_name = c.table_name AND kcu.column_name = c.column_name
+		) AS is_primary_key
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = $1 AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position`, schemaName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	foreignKeys := []*ForeignKeyInfo{}
+	err = pgxscan.Select(ctx, pool, &foreignKeys, `SELECT kcu.table_name, kcu.column_name,
+		ccu.table_name AS foreign_table_name, ccu.column_name AS foreign_column_name,
+		rc.delete_rule, rc.update_rule, tc.initially_deferred = 'YES' AS deferrable
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema
+		JOIN information_schema.constraint_column_usage ccu
+			ON ccu.constraint_name = tc.constraint_name AND ccu.table_schema = tc.table_schema
+		JOIN information_schema.referential_constraints rc
+			ON rc.constraint_name = tc.constraint_name AND rc.constraint_schema = tc.table_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = $1
+		ORDER BY kcu.table_name, kcu.column_name`, schemaName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return columns, foreignKeys, nil
+}
+
+// Returns the gofmt'ed source of package pkg with a model struct for the tables of columns.
+//
+// Foreign keys are written on the referenced model, the way they are declared for AutoMigrate
+// e.g Profile UserProfile `orm:"foreignKey:UserID->ID"` in the User model.
+// Self referencing foreign keys have no relation field, a comment notes them instead.
+func ModelsSource(pkg string, columns []*ColumnInfo, foreignKeys []*ForeignKeyInfo) (string, error) {
+	return ModelsSourceWithOptions(pkg, columns, foreignKeys, Options{})
+}
+
+// Same as ModelsSource but model and table names are resolved with opts
+func ModelsSourceWithOptions(pkg string, columns []*ColumnInfo, foreignKeys []*ForeignKeyInfo, opts Options) (string, error) {
+	tables := []string{}
+	tableColumns := map[string][]*ColumnInfo{}
+	for _, column := range columns {
+		if _, ok := tableColumns[column.TableName]; !ok {
+			tables = append(tables, column.TableName)
+		}
+		tableColumns[column.TableName] = append(tableColumns[column.TableName], column)
+	}
+	sort.Strings(tables)
+
+	imports := map[string]bool{}
+	body := bytes.Buffer{}
+
+	for _, table := range tables {
+		// The table prefix is added back when the table name is resolved
+		base := strings.TrimPrefix(table, opts.TablePrefix)
+		name := goName(opts.singularize(base))
+		body.WriteString(fmt.Sprintf("type %s struct {\n", name))
+
+		for _, column := range tableColumns[table] {
+			typ, tags := columnType(column)
+			if typ.Import != "" {
+				imports[typ.Import] = true
+			}
+
+			body.WriteString(fmt.Sprintf("\t%s %s `json:\"%s\"", goName(column.ColumnName), typ.Name, column.ColumnName))
+			if len(tags) > 0 {
+				body.WriteString(fmt.Sprintf(" orm:\"%s\"", strings.Join(tags, ";")))
+			}
+			body.WriteString("`\n")
+		}
+
+		// Tables referencing this table
+		fields := map[string]bool{}
+		for _, column := range tableColumns[table] {
+			fields[goName(column.ColumnName)] = true
+		}
+
+		for _, fk := range foreignKeys {
+			if fk.ForeignTableName != table {
+				continue
+			}
+
+			// A struct can't contain itself, the foreign key column is kept without a relation
+			if fk.TableName == table {
+				body.WriteString(fmt.Sprintf("\t// %s references %s of this table, self referencing relations are not generated\n",
+					fk.ColumnName, fk.ForeignColumnName))
+				continue
+			}
+
+			tags := []string{fmt.Sprintf("foreignKey:%s->%s", goName(fk.ColumnName), goName(fk.ForeignColumnName))}
+			if fk.DeleteRule != "" && fk.DeleteRule != "NO ACTION" {
+				tags = append(tags, "onDelete:"+fk.DeleteRule)
+			}
+
+			if fk.UpdateRule != "" && fk.UpdateRule != "NO ACTION" {
+				tags = append(tags, "onUpdate:"+fk.UpdateRule)
+			}
+
+			if fk.Deferrable {
+				tags = append(tags, "deferrable")
+			}
+
+			child := goName(opts.singularize(strings.TrimPrefix(fk.TableName, opts.TablePrefix)))
+			field := child
+			if fields[field] {
+				field += goName(fk.ColumnName)
+			}
+			fields[field] = true
+
+			body.WriteString(fmt.Sprintf("\t%s %s `json:\"-\" orm:\"%s\"`\n", field, child, strings.Join(tags, ";")))
+		}
+
+		body.WriteString("}\n\n")
+
+		// Keep the table name if it's not the name the ORM would derive
+		if opts.prefixTableName(opts.tableNameFor(opts.singularize(base))) != table {
+			body.WriteString(fmt.Sprintf("func (%s) TableName() string {\n\treturn %q\n}\n\n", name, base))
+		}
+	}
+
+	src := bytes.Buffer{}
+	src.WriteString("// Code generated by gosqlorm GenerateModels.\n\n")
+	src.WriteString(fmt.Sprintf("package %s\n\n", pkg))
+
+	if len(imports) > 0 {
+		paths := []string{}
+		for path := range imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		// Standard library imports come first
+		src.WriteString("import (\n")
+		for _, path := range paths {
+			if !strings.Contains(path, ".") {
+				src.WriteString(fmt.Sprintf("\t%q\n", path))
+			}
+		}
+		src.WriteString("\n")
+		for _, path := range paths {
+			if strings.Contains(path, ".") {
+				src.WriteString(fmt.Sprintf("\t%q\n", path))
+			}
+		}
+		src.WriteString(")\n\n")
+	}
+
+	src.Write(body.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	return string(formatted), nil
+}
+
+// Returns the Go type and orm tags for column
+func columnType(column *ColumnInfo) (goType, []string) {
+	tags := []string{}
+	nullable := column.IsNullable == "YES" && !column.IsPrimaryKey
+
+	typ, ok := goTypes[column.UdtName]
+	if !ok {
+		// Enums and other user defined types are scanned into strings
+		typ = goType{"string", "varchar(255)", ""}
+	}
+
+	// NULL arrays are scanned into nil slices
+	array := ok && strings.HasPrefix(column.UdtName, "_")
+	if nullable && !array {
+		if nullType, ok := nullGoTypes[column.UdtName]; ok {
+			typ = nullType
+		} else {
+			typ.Name = "*" + typ.Name
+		}
+	}
+
+	if column.IsPrimaryKey {
+		tags = append(tags, "primaryKey")
+	}
+
+	serial := strings.HasPrefix(column.ColumnDefault, "nextval(")
+	if serial {
+		tags = append(tags, "autoIncrement")
+	} else if column.UdtName == "varchar" && column.CharacterMaximumLength > 0 {
+		if column.CharacterMaximumLength != 255 {
+			tags = append(tags, fmt.Sprintf("size:%d", column.CharacterMaximumLength))
+		}
+	} else if sqlType := sqlTypeName(column); sqlType != typ.OrmType {
+		tags = append(tags, "type:"+sqlType)
+	}
+
+	if !nullable && !column.IsPrimaryKey {
+		tags = append(tags, "not null")
+	}
+
+	return typ, tags
+}
+
+// Returns the sql type of column e.g varchar(100)
+func sqlTypeName(column *ColumnInfo) string {
+	name := column.UdtName
+	if sqlType, ok := sqlTypeNames[name]; ok {
+		name = sqlType
+	}
+
+	if column.CharacterMaximumLength > 0 {
+		name = fmt.Sprintf("%s(%d)", name, column.CharacterMaximumLength)
+	}
+
+	return name
+}
+
+// Returns the exported Go name for the snake_case name s e.g user_id -> UserID
+func goName(s string) string {
+	parts := strings.Split(s, "_")
+	for i, part := range parts {
+		if initialisms[part] {
+			parts[i] = strings.ToUpper(part)
+		} else if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
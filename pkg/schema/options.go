@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Options control how table schemas are generated from models.
+// They are passed to GetTableSchemaWithOptions, the orm passes the options of its Config.
+// Schemas parsed with different options are cached separately.
+type Options struct {
+	// Treat non-pointer, non-slice fields as NOT NULL
+	// unless they are tagged with `orm:"null"`
+	InferNotNull bool
+
+	// Use the snake_case type name as the table name without pluralizing it
+	SingularTableNames bool
+
+	// Plural overrides for table names, keyed by the singular snake_case name
+	// of the table or its last word e.g {"staff": "staff", "cactus": "cacti"}
+	Plurals map[string]string
+
+	// Postgres schema of the tables. Defaults to public.
+	// Models can override it with a SchemaName() method.
+	Schema string
+
+	// Prefix added to all table names e.g app_ for app_users,
+	// including the names returned by TableName() methods
+	TablePrefix string
+
+	// Create tables with CREATE TABLE instead of CREATE TABLE IF NOT EXISTS,
+	// so existing tables with the same name are reported as errors
+	StrictMigrate bool
+
+	// Create uniqueIndex constraints as named CREATE UNIQUE INDEX statements
+	// after the table instead of inline UNIQUE constraints, so they can
+	// be dropped by name or recreated CONCURRENTLY
+	UniqueIndexes bool
+
+	// Fail AutoMigrate for models without a primaryKey field
+	// instead of printing a warning
+	RequirePrimaryKey bool
+}
+
+// The postgres schema used when Options.Schema is empty
+const DefaultSchema = "public"
+
+// Returns a string identifying the options in the schema cache.
+// Plurals are written in sorted order so equal options have equal keys.
+func (opts Options) key() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%t|%t|%q|%q|%t|%t|%t", opts.InferNotNull, opts.SingularTableNames, opts.Schema,
+		opts.TablePrefix, opts.StrictMigrate, opts.UniqueIndexes, opts.RequirePrimaryKey)
+
+	singulars := make([]string, 0, len(opts.Plurals))
+	for singular := range opts.Plurals {
+		singulars = append(singulars, singular)
+	}
+	sort.Strings(singulars)
+
+	for _, singular := range singulars {
+		fmt.Fprintf(&b, "|%q=%q", singular, opts.Plurals[singular])
+	}
+	return b.String()
+}
@@ -0,0 +1,191 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// The schemas, extensions, tables, columns, indexes, constraints and types of the live database.
+// Tables, indexes, constraints and types are keyed by their schema qualified name.
+type liveSchema struct {
+	schemas     map[string]bool
+	extensions  map[string]bool
+	columns     map[string]map[string]bool
+	indexes     map[string]bool
+	constraints map[string]bool
+	types       map[string]bool
+}
+
+// Returns the sql string for adding the column for field to an existing table.
+func (t *TableSchema) AddColumnSchema(field *Field) string {
+	// Generate the table schema so the column definition is written to the field
+	t.String(field.dialect)
+
+	definition := strings.TrimSpace(field.buf.String())
+	for _, unique := range t.UniqueFields {
+		if unique != field {
+			continue
+		}
+
+		if name := field.Tags["unique"]; name != "" {
+			definition += " CONSTRAINT " + QuoteIdentifier(name, field.dialect)
+		}
+		definition += " UNIQUE"
+	}
+
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s;", t.QualifiedName(), definition)
+}
+
+// Returns the DDL statements needed to reconcile the live database with models
+// without executing them. New schemas, extensions, custom and enum types, tables, columns,
+// indexes and foreign keys are created in the order AutoMigrate runs them.
+// Existing columns are never altered or dropped.
+//
+// The desired state is generated with GetTableSchema and compared
+// with the current schema from information_schema.
+func MigrationPlan(pool *pgxpool.Pool, driver string, models ...interface{}) ([]string, error) {
+	tables, err := tableSchemas(driver, Options{}, models...)
+	if err != nil {
+		return nil, err
+	}
+
+	return MigrationPlanTables(pool, driver, tables...)
+}
+
+// Same as MigrationPlan for tables parsed with GetTableSchemaWithOptions
+func MigrationPlanTables(pool *pgxpool.Pool, driver string, tables ...*TableSchema) ([]string, error) {
+	live, err := inspectSchema(context.Background(), pool)
+	if err != nil {
+		return nil, err
+	}
+
+	statements, err := migrationStatements(driver, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := []string{}
+	for _, statement := range statements {
+		switch statement.Kind {
+		case schemaStatement:
+			if live.schemas[statement.Name] {
+				continue
+			}
+		case extensionStatement:
+			if live.extensions[statement.Name] {
+				continue
+			}
+		case typeStatement:
+			if live.types[statement.Name] {
+				continue
+			}
+		case tableStatement:
+			// Missing columns are added to existing tables
+			if columns, ok := live.columns[statement.Name]; ok {
+				for _, field := range statement.Table.Fields {
+					if !field.IsRelation() && !columns[SnakeCase(field.Name)] {
+						plan = append(plan, statement.Table.AddColumnSchema(field))
+					}
+				}
+				continue
+			}
+		case indexStatement:
+			if live.indexes[statement.Name] {
+				continue
+			}
+		case foreignKeyStatement:
+			if live.constraints[statement.Name] {
+				continue
+			}
+		}
+
+		plan = append(plan, statement.String())
+	}
+
+	return plan, nil
+}
+
+// Reads the schemas, extensions, tables, columns, indexes, foreign keys and
+// enum and composite types from the database
+func inspectSchema(ctx context.Context, pool *pgxpool.Pool) (*liveSchema, error) {
+	live := &liveSchema{
+		schemas:     map[string]bool{},
+		extensions:  map[string]bool{},
+		columns:     map[string]map[string]bool{},
+		indexes:     map[string]bool{},
+		constraints: map[string]bool{},
+		types:       map[string]bool{},
+	}
+
+	schemas := []string{}
+	if err := pgxscan.Select(ctx, pool, &schemas, "SELECT schema_name FROM information_schema.schemata"); err != nil {
+		return nil, err
+	}
+
+	for _, name := range schemas {
+		live.schemas[name] = true
+	}
+
+	extensions := []string{}
+	if err := pgxscan.Select(ctx, pool, &extensions, "SELECT extname FROM pg_extension"); err != nil {
+		return nil, err
+	}
+
+	for _, name := range extensions {
+		live.extensions[name] = true
+	}
+
+	columns := []struct {
+		TableSchema string
+		TableName   string
+		ColumnName  string
+	}{}
+
+	// Tables without columns are included by the outer join
+	err := pgxscan.Select(ctx, pool, &columns, `SELECT t.table_schema, t.table_name, COALESCE(c.column_name, '') AS column_name
+		FROM information_schema.tables t
+		LEFT JOIN information_schema.columns c ON c.table_schema = t.table_schema AND c.table_name = t.table_name
+		WHERE t.table_type = 'BASE TABLE' AND t.table_schema NOT IN ('pg_catalog', 'information_schema')`)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, column := range columns {
+		table := QualifyName(column.TableSchema, column.TableName)
+		if live.columns[table] == nil {
+			live.columns[table] = map[string]bool{}
+		}
+		live.columns[table][column.ColumnName] = true
+	}
+
+	queries := map[string]map[string]bool{
+		"SELECT schemaname AS schema_name, indexname AS name FROM pg_indexes": live.indexes,
+		`SELECT table_schema AS schema_name, constraint_name AS name FROM information_schema.table_constraints
+			WHERE constraint_type = 'FOREIGN KEY'`: live.constraints,
+		`SELECT n.nspname AS schema_name, t.typname AS name FROM pg_type t
+			JOIN pg_namespace n ON n.oid = t.typnamespace
+			LEFT JOIN pg_class c ON c.oid = t.typrelid
+			WHERE t.typtype = 'e' OR (t.typtype = 'c' AND c.relkind = 'c')`: live.types,
+	}
+
+	for sql, found := range queries {
+		names := []struct {
+			SchemaName string
+			Name       string
+		}{}
+
+		if err := pgxscan.Select(ctx, pool, &names, sql); err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			found[QualifyName(name.SchemaName, name.Name)] = true
+		}
+	}
+
+	return live, nil
+}
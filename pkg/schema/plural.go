@@ -0,0 +1,169 @@
+package schema
+
+import "strings"
+
+// Irregular plurals of common nouns
+var irregularPlurals = map[string]string{
+	"person":    "people",
+	"man":       "men",
+	"woman":     "women",
+	"child":     "children",
+	"mouse":     "mice",
+	"goose":     "geese",
+	"foot":      "feet",
+	"tooth":     "teeth",
+	"ox":        "oxen",
+	"leaf":      "leaves",
+	"half":      "halves",
+	"knife":     "knives",
+	"life":      "lives",
+	"wife":      "wives",
+	"shelf":     "shelves",
+	"wolf":      "wolves",
+	"hero":      "heroes",
+	"potato":    "potatoes",
+	"tomato":    "tomatoes",
+	"echo":      "echoes",
+	"quiz":      "quizzes",
+	"criterion": "criteria",
+	"datum":     "data",
+	"medium":    "media",
+	"vertex":    "vertices",
+	"matrix":    "matrices",
+	"cache":     "caches",
+	"niche":     "niches",
+}
+
+// Nouns that have the same singular and plural form
+var uncountableNouns = map[string]bool{
+	"sheep":       true,
+	"fish":        true,
+	"deer":        true,
+	"series":      true,
+	"species":     true,
+	"news":        true,
+	"information": true,
+	"equipment":   true,
+	"money":       true,
+	"rice":        true,
+	"metadata":    true,
+	"feedback":    true,
+}
+
+// Returns the plural of the snake_case table name s.
+//
+// Only the last word is pluralized e.g user_category -> user_categories.
+// Overrides registered in opts.Plurals take precedence over the built in rules:
+//
+//	irregular nouns: person -> people, child -> children
+//	-s, -ss, -sh, -ch, -x, -z: class -> classes, box -> boxes
+//	-is: analysis -> analyses
+//	consonant + y: category -> categories
+//	words already ending in s are assumed to be plural: settings -> settings
+func (opts Options) pluralize(s string) string {
+	if plural, ok := opts.Plurals[s]; ok {
+		return plural
+	}
+
+	prefix, word := "", s
+	if i := strings.LastIndex(s, "_"); i >= 0 {
+		prefix, word = s[:i+1], s[i+1:]
+	}
+
+	if plural, ok := opts.Plurals[word]; ok {
+		return prefix + plural
+	}
+
+	return prefix + pluralizeWord(word)
+}
+
+// Returns the plural of a single lower case word
+func pluralizeWord(word string) string {
+	if word == "" || uncountableNouns[word] {
+		return word
+	}
+
+	if plural, ok := irregularPlurals[word]; ok {
+		return plural
+	}
+
+	// Already the plural of an irregular noun
+	for _, plural := range irregularPlurals {
+		if word == plural {
+			return word
+		}
+	}
+
+	switch {
+	case hasAnySuffix(word, "ss", "sh", "ch", "x", "z", "us"):
+		return word + "es"
+	case strings.HasSuffix(word, "is"):
+		return word[:len(word)-2] + "es"
+	case strings.HasSuffix(word, "s"):
+		return word
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	}
+
+	return word + "s"
+}
+
+// Returns true if s ends with any of the suffixes
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the singular of the snake_case table name s.
+// It's the inverse of pluralize and is used to name generated models:
+//
+//	-ies: categories -> category
+//	-es after s, x, zz, ch and sh: classes -> class, boxes -> box, matches -> match
+//	-uses after a consonant: statuses -> status, but houses -> house
+//	other -s: users -> user, cases -> case
+func (opts Options) singularize(s string) string {
+	for singular, plural := range opts.Plurals {
+		if plural == s {
+			return singular
+		}
+	}
+
+	prefix, word := "", s
+	if i := strings.LastIndex(s, "_"); i >= 0 {
+		prefix, word = s[:i+1], s[i+1:]
+	}
+
+	return prefix + singularizeWord(word)
+}
+
+// Returns the singular of a single lower case word
+func singularizeWord(word string) string {
+	if uncountableNouns[word] {
+		return word
+	}
+
+	for singular, plural := range irregularPlurals {
+		if word == plural {
+			return singular
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case hasAnySuffix(word, "sses", "zzes", "shes", "ches", "xes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "uses") && len(word) > 4 && !strings.ContainsRune("aeiou", rune(word[len(word)-5])):
+		return word[:len(word)-2]
+	case hasAnySuffix(word, "ss", "us", "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	}
+
+	return word
+}
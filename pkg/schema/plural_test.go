@@ -0,0 +1,85 @@
+package schema
+
+import "testing"
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		singular string
+		plural   string
+	}{
+		{"user", "users"},
+		{"category", "categories"},
+		{"day", "days"},
+		{"class", "classes"},
+		{"box", "boxes"},
+		{"match", "matches"},
+		{"dish", "dishes"},
+		{"status", "statuses"},
+		{"analysis", "analyses"},
+		{"person", "people"},
+		{"child", "children"},
+		{"cache", "caches"},
+		{"sheep", "sheep"},
+		{"settings", "settings"},
+		{"user_category", "user_categories"},
+		{"order_item", "order_items"},
+	}
+
+	for _, tt := range tests {
+		if got := (Options{}).pluralize(tt.singular); got != tt.plural {
+			t.Errorf("pluralize(%q) = %q, want %q", tt.singular, got, tt.plural)
+		}
+	}
+}
+
+func TestSingularize(t *testing.T) {
+	tests := []struct {
+		plural   string
+		singular string
+	}{
+		{"users", "user"},
+		{"categories", "category"},
+		{"days", "day"},
+		{"classes", "class"},
+		{"addresses", "address"},
+		{"boxes", "box"},
+		{"matches", "match"},
+		{"branches", "branch"},
+		{"dishes", "dish"},
+		{"buzzes", "buzz"},
+		{"statuses", "status"},
+		{"buses", "bus"},
+		{"houses", "house"},
+		{"cases", "case"},
+		{"databases", "database"},
+		{"courses", "course"},
+		{"sizes", "size"},
+		{"caches", "cache"},
+		{"people", "person"},
+		{"sheep", "sheep"},
+		{"status", "status"},
+		{"user_categories", "user_category"},
+	}
+
+	for _, tt := range tests {
+		if got := (Options{}).singularize(tt.plural); got != tt.singular {
+			t.Errorf("singularize(%q) = %q, want %q", tt.plural, got, tt.singular)
+		}
+	}
+}
+
+func TestPluralsOption(t *testing.T) {
+	opts := Options{Plurals: map[string]string{"staff": "staff", "cactus": "cacti"}}
+
+	if got := opts.pluralize("cactus"); got != "cacti" {
+		t.Errorf("pluralize(cactus) = %q, want cacti", got)
+	}
+
+	if got := opts.pluralize("hospital_staff"); got != "hospital_staff" {
+		t.Errorf("pluralize(hospital_staff) = %q, want hospital_staff", got)
+	}
+
+	if got := opts.singularize("cacti"); got != "cactus" {
+		t.Errorf("singularize(cacti) = %q, want cactus", got)
+	}
+}
@@ -23,42 +23,103 @@ func hasTag(tag string, tags []string) bool {
 	return false
 }
 
-//  Generates the table schema for struct m
+//  Generates the table schema for struct m with the default Options
 //
-// Returns a pointer to TableSchema and an error if m is not a struct
-// or pointer to a struct
+// Returns a pointer to TableSchema and an error if m is not a struct,
+// pointer to a struct or a slice of either e.g *[]User or *[]*User.
+//
+// The schema is parsed from the zero value of the struct type and cached,
+// so the returned TableSchema is shared and must not be modified.
 func GetTableSchema(m interface{}, dialect string) (*TableSchema, error) {
-	tblSchema := &TableSchema{}
+	return GetTableSchemaWithOptions(m, dialect, Options{})
+}
+
+// Same as GetTableSchema but the schema is generated with opts
+// e.g the table prefix and postgres schema of an orm.Config.
+func GetTableSchemaWithOptions(m interface{}, dialect string, opts Options) (*TableSchema, error) {
+	t := reflect.TypeOf(m)
+	if t == nil {
+		return nil, fmt.Errorf("model is nil")
+	}
+
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct", reflect.TypeOf(m).String())
+	}
+
+	if tblSchema := cachedSchema(t, dialect, opts); tblSchema != nil {
+		return tblSchema, nil
+	}
+
+	// Parse the zero value so the schema does not depend on the values of m
+	v := reflect.New(t).Elem().Interface()
+
+	tblSchema := &TableSchema{opts: opts, dialect: dialect}
 	tblSchema.CompositeIndexes = make(map[string][]*Field)
 	tblSchema.ForeignKeys = make(map[string]*ForeignKey)
-	var v = m
 
-	if IsPointer(v) {
-		v = reflect.ValueOf(v).Elem().Interface()
-	}
+	// The resolved table name is used by all statements built from the schema
+	tblSchema.TableName = opts.tableName(v)
 
-	if !IsStruct(v) {
-		return nil, fmt.Errorf("%s is not a struct", reflect.TypeOf(v).Name())
+	// Only postgres tables are qualified with a schema
+	if dialect == "postgres" {
+		tblSchema.Schema = opts.schemaName(v)
 	}
 
-	tblSchema.TableName = SnakeCase(reflect.TypeOf(v).Name())
 	tblSchema.Fields = make([]*Field, 0)
 	tblSchema.Constraints = make([]*Constraint, 0)
 
-	for i := 0; i < reflect.TypeOf(v).NumField(); i++ {
-		field := reflect.TypeOf(v).Field(i)
-		fieldValue := reflect.ValueOf(v).Field(i)
+	if err := tblSchema.addFields(reflect.ValueOf(v), dialect, map[string]bool{}); err != nil {
+		return nil, err
+	}
+
+	if err := tblSchema.parseConstraints(); err != nil {
+		return nil, err
+	}
+
+	if err := tblSchema.parseIndexes(); err != nil {
+		return nil, err
+	}
+
+	return cacheSchema(t, dialect, opts, tblSchema), nil
+}
+
+// Appends the exported fields of struct value v to the table fields.
+//
+// Fields of anonymous embedded structs are flattened into the table columns,
+// so a shared base struct (ID, CreatedAt, ...) can be embedded in many models.
+// Returns an error if a field name is defined more than once.
+func (tblSchema *TableSchema) addFields(v reflect.Value, dialect string, seen map[string]bool) error {
+	for i := 0; i < v.Type().NumField(); i++ {
+		field := v.Type().Field(i)
+		fieldValue := v.Field(i)
 
 		if field.PkgPath != "" {
 			continue
 		}
 
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && OrmType(&fieldValue) == "" {
+			if err := tblSchema.addFields(fieldValue, dialect, seen); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if seen[field.Name] {
+			return fmt.Errorf("duplicate field %s in %s: embedded struct fields must not collide", field.Name, v.Type().Name())
+		}
+		seen[field.Name] = true
+
 		// Construct field with its tags using reflection
 		fieldSchema := &Field{
 			Name:            field.Name,
 			Type:            field.Type.String(),
 			ReflectObjType:  &field,
 			ReflectObjValue: &fieldValue,
+			Table:           tblSchema,
 			buf:             &bytes.Buffer{},
 			dialect:         dialect,
 		}
@@ -76,7 +137,8 @@ func GetTableSchema(m interface{}, dialect string) (*TableSchema, error) {
 				continue
 			}
 
-			tagParts := strings.Split(tag, ":")
+			// Split on the first colon only, values like default:'12:00' may contain colons
+			tagParts := strings.SplitN(tag, ":", 2)
 			if len(tagParts) == 2 {
 				tagName := strings.TrimSpace(tagParts[0])
 				fieldSchema.Tags[tagName] = strings.TrimSpace(tagParts[1])
@@ -85,13 +147,21 @@ func GetTableSchema(m interface{}, dialect string) (*TableSchema, error) {
 			}
 		}
 
-		tblSchema.Fields = append(tblSchema.Fields, fieldSchema)
-	}
+		if size, ok := fieldSchema.Tags["size"]; ok {
+			if n, err := strconv.Atoi(size); err != nil || n <= 0 {
+				return fmt.Errorf("invalid size %q for field %s: size must be a positive integer", size, field.Name)
+			}
 
-	tblSchema.TableName = GetTableName(v)
+			if !fieldSchema.isSized() {
+				return fmt.Errorf("invalid size for field %s: size is only supported on varchar and char columns", field.Name)
+			}
+		}
 
-	return tblSchema, nil
+		tblSchema.Fields = append(tblSchema.Fields, fieldSchema)
+		tblSchema.addEnum(fieldSchema)
+	}
 
+	return nil
 }
 
 // Calls GetTableSchema to generate the sql for creating the table
@@ -106,77 +176,136 @@ func Schema(v interface{}, dialect string) (string, error) {
 	return tblSchema.String(dialect), nil
 }
 
-// Returns a slice table columns, qualified_column_names and an error
+// Returns a slice table columns, qualified_column_names and an error.
+// Relation fields have no column and are left out of both slices.
 func Columns(v interface{}, dialect string) ([]string, []string, error) {
 	tblSchema, err := GetTableSchema(v, dialect)
 	if err != nil {
 		return []string{}, []string{}, err
 	}
 
-	cols := tblSchema.Fields
-	columns := make([]string, len(cols))
-	qualifiedColumns := make([]string, len(cols))
+	columns, qualifiedColumns := tblSchema.Columns()
+	return columns, qualifiedColumns, nil
+}
 
-	for i, col := range cols {
-		if col.IsForeignKey() {
+// Returns the field names of the table columns and the table qualified column names.
+// Relation fields have no column and are left out of both slices.
+func (t *TableSchema) Columns() ([]string, []string) {
+	columns := []string{}
+	qualifiedColumns := []string{}
+
+	for _, col := range t.Fields {
+		if col.IsRelation() {
 			continue
 		}
 
-		qualifiedColumns[i] = fmt.Sprintf("%s.%s", tblSchema.TableName, SnakeCase(col.Name))
-		columns[i] = col.Name
+		qualifiedColumns = append(qualifiedColumns, fmt.Sprintf("%s.%s", t.ColumnQualifier(), SnakeCase(col.Name)))
+		columns = append(columns, col.Name)
 	}
 
-	return columns, qualifiedColumns, nil
+	return columns, qualifiedColumns
 }
 
-// Returns the string for the Insert query
-func InsertSchema(v interface{}, dialect string) (string, []interface{}, error) {
+// Returns the string for the Insert query.
+// returning lists the columns of the RETURNING clause and defaults to *
+func InsertSchema(v interface{}, dialect string, returning ...string) (string, []interface{}, error) {
+	// The values are read from v, a slice has no single row
+	if !IsStruct(v) && !IsStructPointer(v) {
+		return "", nil, fmt.Errorf("%T is not a struct or pointer to a struct", v)
+	}
+
 	tblSchema, err := GetTableSchema(v, dialect)
 	if err != nil {
 		return "", nil, err
 	}
 
-	insertString, values := tblSchema.InsertSchema(v, dialect)
+	insertString, values := tblSchema.InsertSchema(v, dialect, returning...)
 	return insertString, values, nil
 }
 
-// Returns the string for the UpdateQuery
-func UpdateSchema(v interface{}, filter *query.QueryFilter, dialect string) (string, []interface{}, error) {
+// Returns the string for an InsertQuery without a RETURNING clause.
+// Conflicting rows are skipped if ignoreConflicts is true.
+func InsertSchemaNoReturning(v interface{}, dialect string, ignoreConflicts bool) (string, []interface{}, error) {
+	if !IsStruct(v) && !IsStructPointer(v) {
+		return "", nil, fmt.Errorf("%T is not a struct or pointer to a struct", v)
+	}
+
 	tblSchema, err := GetTableSchema(v, dialect)
 	if err != nil {
 		return "", nil, err
 	}
 
-	if err := filter.Validate(); err != nil {
+	insertString, values := tblSchema.InsertSchemaNoReturning(v, dialect, ignoreConflicts)
+	return insertString, values, nil
+}
+
+// Returns the string for an InsertQuery that skips the row if it conflicts with an existing row
+func InsertIfNotExistsSchema(v interface{}, dialect string, returning ...string) (string, []interface{}, error) {
+	if !IsStruct(v) && !IsStructPointer(v) {
+		return "", nil, fmt.Errorf("%T is not a struct or pointer to a struct", v)
+	}
+
+	tblSchema, err := GetTableSchema(v, dialect)
+	if err != nil {
 		return "", nil, err
 	}
 
-	updateString, values := tblSchema.UpdateSchema(v, dialect)
-	lastParam := len(values)
-	updateString += " WHERE "
+	insertString, values := tblSchema.InsertIfNotExistsSchema(v, dialect, returning...)
+	return insertString, values, nil
+}
 
-	whereClase := filter.Where
+// Returns the string for the UpdateQuery
+func UpdateSchema(v interface{}, filter *query.QueryFilter, dialect string, returning ...string) (string, []interface{}, error) {
+	return updateSchema(v, filter, dialect, true, returning...)
+}
 
-	// Append where clause placeholders to the query
-	// There should probably be a better way to do this
-	// but I'm not sure how to do it right now
-	for i, v := range filter.Args {
-		// Replace $in of the form $1,$2,$3 to start from lastParam
-		if strings.Contains(whereClase, "$"+strconv.FormatInt(int64(i)+1, 10)) {
-			whereClase = strings.Replace(whereClase, "$"+strconv.FormatInt(int64(i)+1, 10), "$"+strconv.FormatInt(int64(lastParam)+1, 10), -1)
-			lastParam++
-		}
+// Same as UpdateSchema but without a RETURNING clause, for statements that can't return rows
+func UpdateSchemaNoReturning(v interface{}, filter *query.QueryFilter, dialect string) (string, []interface{}, error) {
+	return updateSchema(v, filter, dialect, false)
+}
+
+func updateSchema(v interface{}, filter *query.QueryFilter, dialect string, withReturning bool, returning ...string) (string, []interface{}, error) {
+	// The values are read from v, a slice has no single row
+	if !IsStruct(v) && !IsStructPointer(v) {
+		return "", nil, fmt.Errorf("%T is not a struct or pointer to a struct", v)
+	}
+
+	tblSchema, err := GetTableSchema(v, dialect)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return tblSchema.updateWhereSchema(v, filter, dialect, withReturning, returning...)
+}
+
+// Returns the UPDATE statement of v, a pointer to a struct of the table, for the rows matching filter.
+// returning lists the columns of the RETURNING clause and defaults to *
+func (t *TableSchema) UpdateWhereSchema(v interface{}, filter *query.QueryFilter, dialect string, returning ...string) (string, []interface{}, error) {
+	return t.updateWhereSchema(v, filter, dialect, true, returning...)
+}
+
+// Same as UpdateWhereSchema but without a RETURNING clause
+func (t *TableSchema) UpdateWhereSchemaNoReturning(v interface{}, filter *query.QueryFilter, dialect string) (string, []interface{}, error) {
+	return t.updateWhereSchema(v, filter, dialect, false)
+}
 
-		values = append(values, v)
+func (t *TableSchema) updateWhereSchema(v interface{}, filter *query.QueryFilter, dialect string, withReturning bool, returning ...string) (string, []interface{}, error) {
+	if err := filter.Validate(); err != nil {
+		return "", nil, err
 	}
 
-	updateString += whereClase
+	// The statement is built with $n placeholders and rebound to the dialect,
+	// the where clause placeholders are numbered after the SET values.
+	updateString, values := t.UpdateSchema(v, "postgres")
+	updateString += " WHERE " + query.ShiftPlaceholders(filter.Where, len(values))
+	values = append(values, filter.Args...)
 
 	// Add returning clause
-	if dialect == "postgres" {
-		updateString += " RETURNING *"
+	if withReturning && dialect == "postgres" {
+		updateString += ReturningClause(returning)
 	}
 
+	updateString, values = query.Rebind(dialectFor(dialect), updateString, values)
 	return updateString, values, nil
 }
 
@@ -190,52 +319,127 @@ func DeleteSchema(v interface{}, dialect string) (string, error) {
 }
 
 // Creates all tables, constraints and relations.
+// Tables are created in foreign key dependency order, referenced tables first.
 // NB: This does not alter existing table schema and is not recommendated
 // as a solid migration option.
 func AutoMigrate(pool *pgxpool.Pool, driver string, models ...interface{}) error {
-	schemasObjects := map[string]*TableSchema{}
-	for _, model := range models {
-		s, err := GetTableSchema(model, driver)
-		if err != nil {
-			return err
-		}
+	return AutoMigrateContext(context.Background(), pool, driver, models...)
+}
+
+// Same as AutoMigrate but all statements run with ctx.
+// The migration stops with the context error when ctx is cancelled or its deadline expires.
+func AutoMigrateContext(ctx context.Context, pool *pgxpool.Pool, driver string, models ...interface{}) error {
+	_, err := AutoMigrateReport(ctx, pool, driver, models...)
+	return err
+}
+
+// Result of a statement run by AutoMigrateReport
+type MigrationResult struct {
+	SQL string
 
-		schemasObjects[s.TableName] = s
+	// True if the statement ran without error
+	Executed bool
 
-		// Populate the table table schema and foreign keys by calling String() method
-		s.String(driver)
+	// Error returned by the statement. Statements skipped because the
+	// enum type or foreign key already exists are not executed and keep their error.
+	Err error
+}
+
+// Same as AutoMigrateContext but returns the result of each statement in the order they ran.
+// The report is returned with the error if the migration stops.
+func AutoMigrateReport(ctx context.Context, pool *pgxpool.Pool, driver string, models ...interface{}) ([]MigrationResult, error) {
+	tables, err := tableSchemas(driver, Options{}, models...)
+	if err != nil {
+		return []MigrationResult{}, err
 	}
 
-	for tableName, tableSchema := range schemasObjects {
-		// Create the table if it doesn't exist
-		sql := tableSchema.String(driver)
-		fmt.Println(sql)
+	return AutoMigrateTables(ctx, pool, driver, tables...)
+}
 
-		// Execute create table statement
-		_, err := pool.Exec(context.Background(), sql)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error creating table %s: %v", tableName, err)
+// Same as AutoMigrateReport for tables parsed with GetTableSchemaWithOptions.
+// The RequirePrimaryKey and StrictMigrate options of each table apply to it.
+//
+// Without StrictMigrate, the migration goes on when a table can't be created.
+// The indexes and foreign keys of the table are skipped and the errors
+// of the failed tables are returned once the other statements ran.
+func AutoMigrateTables(ctx context.Context, pool *pgxpool.Pool, driver string, tables ...*TableSchema) ([]MigrationResult, error) {
+	report := []MigrationResult{}
+	tables = uniqueTables(tables)
+
+	// Tables without a primary key can't be updated or deleted by key
+	for _, tableSchema := range tables {
+		if len(tableSchema.PrimaryKeyField()) > 0 {
 			continue
 		}
 
-		// If the tableName has no foreignKeys, go to the next table
-		if _, ok := ForeignKeys[tableName]; !ok {
+		if tableSchema.opts.RequirePrimaryKey {
+			return report, fmt.Errorf("table %s has no primary key", tableSchema.QualifiedName())
+		}
+
+		fmt.Fprintf(os.Stderr, "warning: table %s has no primary key\n", tableSchema.QualifiedName())
+	}
+
+	statements, err := migrationStatements(driver, tables)
+	if err != nil {
+		return report, err
+	}
+
+	exec := func(sql string) error {
+		_, err := pool.Exec(ctx, sql)
+		report = append(report, MigrationResult{SQL: sql, Executed: err == nil, Err: err})
+		return err
+	}
+
+	// Indexes and foreign keys of tables that could not be created are skipped
+	failed := map[string]bool{}
+	tableErrs := []string{}
+	for _, statement := range statements {
+		switch statement.Kind {
+		case indexStatement:
+			if failed[statement.Table.QualifiedName()] {
+				continue
+			}
+		case foreignKeyStatement:
+			if failed[statement.Table.QualifiedName()] || failed[statement.References] {
+				continue
+			}
+		}
+
+		err := exec(statement.SQL)
+		if err == nil {
 			continue
 		}
 
-		// Create the foreign keys for tableName
-		for _, fk := range ForeignKeys[tableName] {
-			sql := fk.String()
-			fmt.Println(sql)
-			_, err = pool.Exec(context.Background(), sql)
+		switch statement.Kind {
+		case typeStatement, foreignKeyStatement:
+			// Types and foreign keys have no IF NOT EXISTS
+			if alreadyExists(err) {
+				continue
+			}
+		case tableStatement:
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
 
-			if err != nil {
-				if !strings.Contains(err.Error(), "already exists") {
-					return err
-				}
+			// Existing tables are errors in strict mode
+			if !statement.Table.opts.StrictMigrate {
+				failed[statement.Name] = true
+				tableErrs = append(tableErrs, fmt.Sprintf("%s: %v", statement.Name, err))
+				continue
 			}
 		}
+
+		return report, err
 	}
 
-	return nil
+	if len(tableErrs) > 0 {
+		return report, fmt.Errorf("error creating tables: %s", strings.Join(tableErrs, "; "))
+	}
+
+	return report, nil
+}
+
+// Returns true if err is returned for creating an object that already exists
+func alreadyExists(err error) bool {
+	return strings.Contains(err.Error(), "already exists")
 }
@@ -0,0 +1,310 @@
+package schema
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gosqlorm/pkg/query"
+)
+
+type columnUser struct {
+	ID        int `orm:"primaryKey;autoIncrement"`
+	FirstName string
+}
+
+type qualifiedColumnUser struct {
+	ID        int `orm:"primaryKey;autoIncrement"`
+	FirstName string
+}
+
+func (qualifiedColumnUser) TableName() string { return "app.users" }
+
+type reservedOrder struct {
+	ID int `orm:"primaryKey;autoIncrement"`
+}
+
+func (reservedOrder) TableName() string { return "order" }
+
+func TestColumns(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     interface{}
+		qualified []string
+	}{
+		{"table", &columnUser{}, []string{"column_users.id", "column_users.first_name"}},
+		{"schema qualified table", &qualifiedColumnUser{}, []string{"users.id", "users.first_name"}},
+		{"reserved word", &reservedOrder{}, []string{`"order".id`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, qualified, err := Columns(tt.model, "postgres")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(columns) != len(qualified) {
+				t.Errorf("got %d columns and %d qualified columns", len(columns), len(qualified))
+			}
+
+			if !reflect.DeepEqual(qualified, tt.qualified) {
+				t.Errorf("qualified columns = %v, want %v", qualified, tt.qualified)
+			}
+		})
+	}
+}
+
+type writeUser struct {
+	ID   int `orm:"primaryKey;autoIncrement"`
+	Name string
+}
+
+func TestWriteSchemaOfStructValues(t *testing.T) {
+	filter := &query.QueryFilter{Where: "id = $1", Args: query.Args{1}}
+
+	for _, v := range []interface{}{writeUser{Name: "ann"}, &writeUser{Name: "ann"}} {
+		sql, values, err := InsertSchema(v, "postgres")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := "INSERT INTO public.write_users (name) VALUES ($1) RETURNING *"; sql != want {
+			t.Errorf("InsertSchema(%T) = %q, want %q", v, sql, want)
+		}
+
+		if !reflect.DeepEqual(values, []interface{}{"ann"}) {
+			t.Errorf("InsertSchema(%T) values = %v", v, values)
+		}
+
+		sql, values, err = UpdateSchema(v, filter, "postgres")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := "UPDATE public.write_users SET name = $1 WHERE id = $2 RETURNING *"; sql != want {
+			t.Errorf("UpdateSchema(%T) = %q, want %q", v, sql, want)
+		}
+
+		if !reflect.DeepEqual(values, []interface{}{"ann", 1}) {
+			t.Errorf("UpdateSchema(%T) values = %v", v, values)
+		}
+	}
+}
+
+type sizedProduct struct {
+	ID   int    `orm:"primaryKey;autoIncrement"`
+	Code string `orm:"type:char(3);size:2"`
+	Name string `orm:"size:100"`
+}
+
+type sizedInteger struct {
+	ID    int `orm:"primaryKey;autoIncrement"`
+	Stock int `orm:"size:4"`
+}
+
+type sizedText struct {
+	ID   int    `orm:"primaryKey;autoIncrement"`
+	Body string `orm:"type:text;size:100"`
+}
+
+type sizedInvalid struct {
+	ID   int    `orm:"primaryKey;autoIncrement"`
+	Name string `orm:"size:0"`
+}
+
+func TestSizeTag(t *testing.T) {
+	tblSchema, err := GetTableSchema(&sizedProduct{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for column, want := range map[string]string{"code": "char(2)", "name": "varchar(100)"} {
+		if got := tblSchema.FieldByColumn(column).SQLType(); got != want {
+			t.Errorf("SQLType() of %s = %s, want %s", column, got, want)
+		}
+	}
+
+	// Only varchar and char columns have a length
+	for _, model := range []interface{}{&sizedInteger{}, &sizedText{}, &sizedInvalid{}} {
+		if _, err := GetTableSchema(model, "postgres"); err == nil {
+			t.Errorf("GetTableSchema(%T) returned no error", model)
+		}
+	}
+}
+
+type EmbeddedBase struct {
+	ID        int `orm:"primaryKey;autoIncrement"`
+	CreatedAt time.Time
+}
+
+type EmbeddedAudit struct {
+	EmbeddedBase
+	UpdatedBy string
+}
+
+type embeddedPost struct {
+	EmbeddedAudit
+	Title string
+}
+
+type embeddedDuplicate struct {
+	EmbeddedBase
+	ID int
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	tblSchema, err := GetTableSchema(&embeddedPost{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fields of embedded structs are flattened in declaration order
+	names := []string{}
+	for _, field := range tblSchema.Fields {
+		names = append(names, field.Name)
+	}
+
+	if want := []string{"ID", "CreatedAt", "UpdatedBy", "Title"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("fields = %v, want %v", names, want)
+	}
+
+	if _, err := GetTableSchema(&embeddedDuplicate{}, "postgres"); err == nil {
+		t.Error("embedded field colliding with a field of the model returned no error")
+	}
+}
+
+type scalarKeyPost struct {
+	ID       int `orm:"primaryKey;autoIncrement"`
+	AuthorID int `orm:"foreignKey:users->id;onDelete:cascade"`
+	Title    string
+}
+
+func TestScalarForeignKeyColumns(t *testing.T) {
+	tblSchema, err := GetTableSchema(&scalarKeyPost{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The field is a column of the table, not a relation
+	if ddl := tblSchema.String("postgres"); !strings.Contains(ddl, "author_id INTEGER") {
+		t.Errorf("table has no author_id column:\n%s", ddl)
+	}
+
+	columns, _, err := Columns(&scalarKeyPost{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"ID", "AuthorID", "Title"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+
+	want := "ALTER TABLE public.scalar_key_posts ADD CONSTRAINT scalar_key_posts_author_id_fkey FOREIGN KEY (author_id) REFERENCES public.users (id) ON DELETE CASCADE"
+	if len(tblSchema.ForeignKeys) != 1 {
+		t.Fatalf("table has %d foreign keys, want 1", len(tblSchema.ForeignKeys))
+	}
+
+	for _, fk := range tblSchema.ForeignKeys {
+		if got := fk.String(); !strings.Contains(got, want) {
+			t.Errorf("foreign key = %s, want %s", got, want)
+		}
+	}
+}
+
+type relationColumnUser struct {
+	ID     int             `orm:"primaryKey;autoIncrement"`
+	Tokens []fkActionChild `orm:"foreignKey:ParentID->ID"`
+	Email  string
+}
+
+func TestColumnsSkipRelations(t *testing.T) {
+	columns, qualified, err := Columns(&relationColumnUser{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"ID", "Email"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+
+	if len(qualified) != 2 {
+		t.Errorf("qualified columns = %v, want 2 columns", qualified)
+	}
+}
+
+type returningUser struct {
+	ID    int `orm:"primaryKey;autoIncrement"`
+	Name  string
+	Email string
+}
+
+func TestUpdateSchemaReturning(t *testing.T) {
+	filter := &query.QueryFilter{Where: "id = $1", Args: query.Args{1}}
+
+	tests := []struct {
+		returning []string
+		want      string
+	}{
+		{nil, "UPDATE public.returning_users SET name = $1, email = $2 WHERE id = $3 RETURNING *"},
+		{[]string{"ID"}, "UPDATE public.returning_users SET name = $1, email = $2 WHERE id = $3 RETURNING id"},
+		{[]string{"ID", "Email"}, "UPDATE public.returning_users SET name = $1, email = $2 WHERE id = $3 RETURNING id, email"},
+	}
+
+	for _, tt := range tests {
+		sql, _, err := UpdateSchema(&returningUser{Name: "ann", Email: "ann@example.com"}, filter, "postgres", tt.returning...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sql != tt.want {
+			t.Errorf("UpdateSchema(%v) = %q, want %q", tt.returning, sql, tt.want)
+		}
+	}
+}
+
+type nullableProfile struct {
+	ID       int `orm:"primaryKey;autoIncrement"`
+	Nickname *string
+	Age      *int
+}
+
+func TestNilPointerValues(t *testing.T) {
+	age := 30
+	profile := &nullableProfile{Age: &age}
+
+	_, values, err := InsertSchema(profile, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nil pointers are untyped nil arguments, so they are inserted as NULL
+	if len(values) != 2 || values[0] != nil || values[1] != &age {
+		t.Errorf("InsertSchema values = %#v, want [nil %p]", values, &age)
+	}
+
+	filter := &query.QueryFilter{Where: "id = $1", Args: query.Args{1}}
+	_, values, err = UpdateSchema(profile, filter, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(values) != 3 || values[0] != nil || values[1] != &age {
+		t.Errorf("UpdateSchema values = %#v, want [nil %p 1]", values, &age)
+	}
+}
+
+func TestRequirePrimaryKey(t *testing.T) {
+	tblSchema, err := GetTableSchemaWithOptions(&noKeyLog{}, "postgres", Options{RequirePrimaryKey: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The primary keys are checked before any statement runs
+	_, err = AutoMigrateTables(context.Background(), nil, "postgres", tblSchema)
+	if err == nil || !strings.Contains(err.Error(), "no_key_logs has no primary key") {
+		t.Errorf("AutoMigrateTables() error = %v, want no primary key error", err)
+	}
+}
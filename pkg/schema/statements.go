@@ -0,0 +1,150 @@
+package schema
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kinds of the statements of a migration
+type statementKind int
+
+const (
+	schemaStatement statementKind = iota
+	extensionStatement
+	typeStatement
+	tableStatement
+	indexStatement
+	foreignKeyStatement
+)
+
+// A DDL statement run by AutoMigrate, planned by MigrationPlan and written by DumpSchema
+type migrationStatement struct {
+	SQL  string
+	Kind statementKind
+
+	// Name of the created object, compared with the live database by MigrationPlan.
+	// Types, tables, indexes and foreign keys have schema qualified names.
+	Name string
+
+	// Table of table, index and foreign key statements
+	Table *TableSchema
+
+	// Qualified name of the table referenced by foreign key statements
+	References string
+}
+
+// Returns the SQL of the statement terminated with a semicolon
+func (s migrationStatement) String() string {
+	return strings.TrimSuffix(s.SQL, ";") + ";"
+}
+
+// Returns the statements creating tables in the order they must run.
+//
+// Schemas other than public come first, followed by the extensions of column defaults,
+// the custom and enum types, the tables each followed by its indexes in foreign key
+// dependency order and finally the foreign keys, once all tables exist.
+// Only the foreign keys declared by tables on one of tables are created.
+// AutoMigrate, MigrationPlan and DumpSchema share the statements so they can't drift.
+func migrationStatements(driver string, tables []*TableSchema) ([]migrationStatement, error) {
+	tables = sortTables(uniqueTables(tables))
+	foreignKeys := tableForeignKeys(tables)
+
+	statements := []migrationStatement{}
+	seen := map[statementKind]map[string]bool{}
+	add := func(s migrationStatement) {
+		key := s.Name
+		if key == "" {
+			key = s.SQL
+		}
+
+		if seen[s.Kind] == nil {
+			seen[s.Kind] = map[string]bool{}
+		}
+
+		if seen[s.Kind][key] {
+			return
+		}
+		seen[s.Kind][key] = true
+		statements = append(statements, s)
+	}
+
+	for _, tableSchema := range tables {
+		if tableSchema.Schema != "" && tableSchema.Schema != DefaultSchema {
+			add(migrationStatement{SQL: CreateSchemaSQL(tableSchema.Schema), Kind: schemaStatement, Name: tableSchema.Schema})
+		}
+	}
+
+	// Extensions only exist in postgres
+	if driver == "postgres" {
+		for _, tableSchema := range tables {
+			for _, extension := range tableSchema.Extensions() {
+				sql, err := CreateExtensionSQL(extension)
+				if err != nil {
+					return nil, err
+				}
+				add(migrationStatement{SQL: sql, Kind: extensionStatement, Name: extension})
+			}
+		}
+	}
+
+	for _, tableSchema := range tables {
+		for _, customType := range tableSchema.customTypes() {
+			add(customType)
+		}
+
+		for _, enum := range tableSchema.Enums {
+			add(migrationStatement{SQL: enum.String(), Kind: typeStatement, Name: QualifyName(enum.Schema, enum.Name)})
+		}
+	}
+
+	for _, tableSchema := range tables {
+		add(migrationStatement{
+			SQL:   tableSchema.String(driver),
+			Kind:  tableStatement,
+			Name:  tableSchema.QualifiedName(),
+			Table: tableSchema,
+		})
+
+		for _, idx := range tableSchema.Indexes {
+			add(migrationStatement{
+				SQL:   idx.String(),
+				Kind:  indexStatement,
+				Name:  QualifyName(tableSchema.Schema, idx.Name),
+				Table: tableSchema,
+			})
+		}
+	}
+
+	for _, tableSchema := range tables {
+		for _, fk := range foreignKeys[tableSchema.QualifiedName()] {
+			add(migrationStatement{
+				SQL:   fk.String(),
+				Kind:  foreignKeyStatement,
+				Name:       QualifyName(fk.Schema, fk.ConstraintName),
+				Table:      tableSchema,
+				References: fk.ParentTable,
+			})
+		}
+	}
+
+	return statements, nil
+}
+
+// Returns the foreign keys declared by tables keyed by the qualified name of the table
+// of their column, sorted by constraint name. The foreign key of a relation is
+// declared by the parent table on the table of the related model.
+func tableForeignKeys(tables []*TableSchema) map[string][]*ForeignKey {
+	foreignKeys := map[string][]*ForeignKey{}
+	for _, tableSchema := range tables {
+		for _, fk := range tableSchema.ForeignKeys {
+			foreignKeys[fk.TableName] = append(foreignKeys[fk.TableName], fk)
+		}
+	}
+
+	for _, fks := range foreignKeys {
+		sort.Slice(fks, func(i, j int) bool {
+			return fks[i].ConstraintName < fks[j].ConstraintName
+		})
+	}
+	return foreignKeys
+}
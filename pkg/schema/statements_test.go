@@ -0,0 +1,101 @@
+package schema
+
+import "testing"
+
+func TestMigrationStatements(t *testing.T) {
+	tables := []*TableSchema{}
+	for _, model := range []interface{}{&dumpInvoice{}, &dumpCustomer{}, &dumpCustomer{}} {
+		tblSchema, err := GetTableSchema(model, "postgres")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, tblSchema)
+	}
+
+	statements, err := migrationStatements("postgres", tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []statementKind{
+		schemaStatement,
+		extensionStatement,
+		typeStatement,
+		typeStatement,
+		tableStatement,
+		indexStatement,
+		tableStatement,
+		foreignKeyStatement,
+	}
+
+	if len(statements) != len(want) {
+		t.Fatalf("got %d statements, want %d: %v", len(statements), len(want), statements)
+	}
+
+	for i, statement := range statements {
+		if statement.Kind != want[i] {
+			t.Errorf("statement %d %q has kind %d, want %d", i, statement.SQL, statement.Kind, want[i])
+		}
+	}
+
+	// Table and index statements belong to their table, which is created before its dependents
+	if statements[4].Table.TableName != "dump_customers" || statements[5].Table != statements[4].Table {
+		t.Errorf("customers table and index are not first: %q, %q", statements[4].SQL, statements[5].SQL)
+	}
+
+	if statements[6].Name != "sales.dump_invoices" {
+		t.Errorf("table statement name = %s, want sales.dump_invoices", statements[6].Name)
+	}
+}
+
+type fkAuthor struct {
+	ID    int      `orm:"primaryKey;autoIncrement"`
+	Books []fkBook `orm:"foreignKey:AuthorID->ID"`
+}
+
+type fkBook struct {
+	ID       int `orm:"primaryKey;autoIncrement"`
+	AuthorID int
+}
+
+func TestMigrationStatementsForeignKeys(t *testing.T) {
+	author, err := GetTableSchema(&fkAuthor{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book, err := GetTableSchema(&fkBook{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		tables []*TableSchema
+		want   int
+	}{
+		// The foreign key on fk_books is declared by fk_authors
+		{"declaring table not migrated", []*TableSchema{book}, 0},
+		{"both tables", []*TableSchema{book, author}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := migrationStatements("postgres", tt.tables)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			foreignKeys := 0
+			for _, statement := range statements {
+				if statement.Kind == foreignKeyStatement {
+					foreignKeys++
+				}
+			}
+
+			if foreignKeys != tt.want {
+				t.Errorf("got %d foreign key statements, want %d: %v", foreignKeys, tt.want, statements)
+			}
+		})
+	}
+}
@@ -4,17 +4,29 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 )
 
 type TableSchema struct {
 	TableName        string
+	Schema           string
 	Fields           []*Field
 	PrimaryKey       *Field
 	ForeignKeys      map[string]*ForeignKey
 	UniqueFields     []*Field
 	CompositeIndexes map[string][]*Field
 	Constraints      []*Constraint
+	Indexes          []*Index
+	Enums            []*EnumType
+
+	// The options the schema was parsed with
+	opts Options
+
+	// The dialect the schema was parsed for
+	dialect string
 
 	buf      *bytes.Buffer
 	migrated bool
@@ -22,12 +34,42 @@ type TableSchema struct {
 
 type ForeignKey struct {
 	ConstraintName string
+	Schema         string
 	FK             string
 	OnDelete       string
 	OnUpdate       string
 	TableName      string
 	ParentTable    string
 	ParentPkColumn string
+
+	// Checked at commit instead of after each statement.
+	// Needed for tables that reference each other.
+	Deferrable bool
+}
+
+// Index is an index created separately from the table
+// with CREATE INDEX
+type Index struct {
+	Name      string
+	TableName string
+	Columns   []string
+	Unique    bool
+
+	// Index method e.g GIN. Empty uses the default method
+	Method string
+
+	// Condition of a partial index e.g status = 'active'. Empty indexes all rows
+	Where string
+
+	// The dialect the name is quoted for
+	dialect string
+}
+
+// EnumType is a postgres enum type used by a column
+type EnumType struct {
+	Name   string
+	Schema string
+	Values []string
 }
 
 type Constraint struct {
@@ -36,10 +78,18 @@ type Constraint struct {
 	Field *Field
 }
 
+// Foreign keys of all parsed tables keyed by the qualified name of the table of their column.
+// Migrations only create the foreign keys of the TableSchemas being migrated.
 var ForeignKeys = make(map[string][]*ForeignKey)
 
+// Guards the sql generation of table schemas and the registration of foreign keys in ForeignKeys
+var ddlMu sync.Mutex
+
 // Returns the sql string for creating the table
 func (t *TableSchema) String(dialect string) string {
+	ddlMu.Lock()
+	defer ddlMu.Unlock()
+
 	if t.migrated {
 		return t.buf.String()
 	}
@@ -55,40 +105,239 @@ func (t *TableSchema) String(dialect string) string {
 	return t.buf.String()
 }
 
-func (t *TableSchema) Flush() { t.buf.Reset() }
+// Clears the sql generated by String, including the column definitions of the fields,
+// so the next call to String generates it again e.g after the fields are changed.
+func (t *TableSchema) Flush() {
+	ddlMu.Lock()
+	defer ddlMu.Unlock()
 
-func (t *TableSchema) WriteHeader() {
-	t.buf.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n", t.TableName))
+	t.buf = &bytes.Buffer{}
+	for _, field := range t.Fields {
+		field.buf = &bytes.Buffer{}
+	}
+	t.migrated = false
+}
 
+// Returns the fields tagged with primaryKey in field order.
+// A composite primary key has more than one field.
+//
+// Unlike TableSchema.PrimaryKey, it does not depend on the table sql being generated first.
+func (t *TableSchema) PrimaryKeyField() []*Field {
+	fields := []*Field{}
+	for _, field := range t.Fields {
+		if field.IsPrimaryKey() && !field.IsRelation() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
 }
 
-func (t *TableSchema) WriteColumns(dialect string) {
-	for i, field := range t.Fields {
-		if i > 0 && i <= len(t.Fields)-1 {
-			if !field.IsForeignKey() {
-				t.buf.WriteString(",\n")
+// Sets the primary key, unique fields and composite unique indexes of the table
+// and registers its foreign keys in ForeignKeys.
+func (t *TableSchema) parseConstraints() error {
+	if pkFields := t.PrimaryKeyField(); len(pkFields) > 0 {
+		t.PrimaryKey = pkFields[0]
+	}
+
+	for _, field := range t.Fields {
+		if _, ok := field.Tags["unique"]; ok {
+			t.UniqueFields = append(t.UniqueFields, field)
+		}
+
+		if name, ok := field.Tags["uniqueIndex"]; ok {
+			t.CompositeIndexes[name] = append(t.CompositeIndexes[name], field)
+		}
+
+		if _, ok := field.Tags["foreignKey"]; ok {
+			if err := t.addForeignKey(field); err != nil {
+				return err
 			}
 		}
+	}
+
+	return nil
+}
+
+// Registers the foreign key of field in ForeignKeys.
+// The tag of a relation is of the form foreignKey:ChildFK->ParentPK and the tag
+// of a scalar foreign key column is of the form foreignKey:parent_table->parent_column.
+func (t *TableSchema) addForeignKey(field *Field) error {
+	// Spaces around the keys e.g UserID -> ID are ignored
+	fks := strings.Split(field.Tags["foreignKey"], "->")
+	for i := range fks {
+		fks[i] = strings.TrimSpace(fks[i])
+	}
+
+	if len(fks) != 2 || fks[0] == "" || fks[1] == "" {
+		return fmt.Errorf("invalid foreign key definition: %s", field.Tags["foreignKey"])
+	}
+
+	constraintName := fmt.Sprintf("%s_%s_fkey", SnakeCase(t.TableName), SnakeCase(field.Name))
+
+	var fk *ForeignKey
+	if field.IsRelation() {
+		// The foreign key column ChildFK is in the table of the related model
+		fkStructType := field.ReflectObjValue.Interface()
+		fk = &ForeignKey{
+			ConstraintName: constraintName,
+			Schema:         t.opts.schemaName(fkStructType),
+			FK:             fks[0],
+			ParentPkColumn: fks[1],
+			TableName:      t.qualify(t.opts.schemaName(fkStructType), t.opts.tableName(fkStructType)),
+			ParentTable:    t.QualifiedName(),
+		}
+	} else {
+		// The field is the foreign key column, referencing the column of the table
+		// in the tag e.g foreignKey:users->id. Unqualified tables are in the schema of t.
+		fk = &ForeignKey{
+			ConstraintName: constraintName,
+			Schema:         t.Schema,
+			FK:             field.Name,
+			ParentPkColumn: fks[1],
+			TableName:      t.QualifiedName(),
+			ParentTable:    t.qualify(t.Schema, fks[0]),
+		}
+	}
+	tableName := fk.TableName
+
+	if v, ok := field.Tags["onDelete"]; ok {
+		action, err := referentialAction(v)
+		if err != nil {
+			return fmt.Errorf("invalid onDelete for field %s: %w", field.Name, err)
+		}
+		fk.OnDelete = fmt.Sprintf(" ON DELETE %s", action)
+	}
+
+	if v, ok := field.Tags["onUpdate"]; ok {
+		action, err := referentialAction(v)
+		if err != nil {
+			return fmt.Errorf("invalid onUpdate for field %s: %w", field.Name, err)
+		}
+		fk.OnUpdate = fmt.Sprintf(" ON UPDATE %s", action)
+	}
+
+	_, fk.Deferrable = field.Tags["deferrable"]
+
+	t.ForeignKeys[constraintName] = fk
+
+	ddlMu.Lock()
+	defer ddlMu.Unlock()
+
+	if !foreignKeyExists(tableName, constraintName) {
+		ForeignKeys[tableName] = append(ForeignKeys[tableName], fk)
+	}
+
+	return nil
+}
+
+// Actions allowed in ON DELETE and ON UPDATE clauses
+var referentialActions = map[string]bool{
+	"CASCADE":     true,
+	"SET NULL":    true,
+	"SET DEFAULT": true,
+	"RESTRICT":    true,
+	"NO ACTION":   true,
+}
+
+// Returns the upper case referential action v e.g set null -> SET NULL.
+// Returns an error if v is not one of referentialActions.
+func referentialAction(v string) (string, error) {
+	action := strings.ToUpper(strings.Join(strings.Fields(v), " "))
+	if !referentialActions[action] {
+		return "", fmt.Errorf("%q is not one of CASCADE, SET NULL, SET DEFAULT, RESTRICT or NO ACTION", v)
+	}
+	return action, nil
+}
+
+// Returns the field for the snake_case column name or nil if the table has no such column
+func (t *TableSchema) FieldByColumn(column string) *Field {
+	for _, field := range t.Fields {
+		if SnakeCase(field.Name) == column && !field.IsRelation() {
+			return field
+		}
+	}
+	return nil
+}
+
+// Returns the quoted schema qualified table name e.g public.users
+func (t *TableSchema) QualifiedName() string {
+	return t.qualify(t.Schema, t.TableName)
+}
+
+// Returns the quoted name qualified with schemaName for postgres.
+// Tables of other dialects have no schema, their names are only quoted.
+func (t *TableSchema) qualify(schemaName, name string) string {
+	if t.dialect != "postgres" && !strings.Contains(name, ".") {
+		return QuoteIdentifier(name, t.dialect)
+	}
+	return QualifyName(schemaName, name)
+}
+
+// Returns the quoted name that qualifies the columns of the table e.g users in users.id.
+// A table name with a schema e.g app.users is referred to by its last segment.
+func (t *TableSchema) ColumnQualifier() string {
+	return QuoteIdentifier(unqualifiedName(t.TableName), t.dialect)
+}
+
+func (t *TableSchema) WriteHeader() {
+	if t.opts.StrictMigrate {
+		t.buf.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", t.QualifiedName()))
+		return
+	}
+
+	t.buf.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n", t.QualifiedName()))
+}
 
-		field.Table = t
-		t.buf.WriteString(field.String())
+// Writes the column definitions of the table.
+// Relations are not columns, so they are left out.
+func (t *TableSchema) WriteColumns(dialect string) {
+	columns := []string{}
+	for _, field := range t.Fields {
+		if field.IsRelation() {
+			continue
+		}
+		columns = append(columns, field.String())
 	}
+
+	t.buf.WriteString(strings.Join(columns, ",\n"))
 }
 
 func (t *TableSchema) WritePrimaryKey() {
-	if t.PrimaryKey != nil {
-		t.buf.WriteString(fmt.Sprintf(",\nPRIMARY KEY (%s)", SnakeCase(t.PrimaryKey.Name)))
+	pkFields := t.PrimaryKeyField()
+	if len(pkFields) == 0 {
+		return
 	}
+
+	columns := make([]string, len(pkFields))
+	for i, field := range pkFields {
+		columns[i] = SnakeCase(field.Name)
+	}
+
+	t.buf.WriteString(fmt.Sprintf(",\nPRIMARY KEY (%s)", strings.Join(columns, ", ")))
 }
 
+// Writes the UNIQUE constraints of the unique fields.
+// The constraint is named if the tag has a value e.g unique:uq_users_email,
+// so it can be referenced in ON CONFLICT ON CONSTRAINT.
 func (t *TableSchema) WriteUniqueFields() {
 	for _, field := range t.UniqueFields {
-		t.buf.WriteString(fmt.Sprintf(",\nUNIQUE (%s)", SnakeCase(field.Name)))
+		t.buf.WriteString(",\n")
+		if name := field.Tags["unique"]; name != "" {
+			t.buf.WriteString(fmt.Sprintf("CONSTRAINT %s ", QuoteIdentifier(name, t.dialect)))
+		}
+		t.buf.WriteString(fmt.Sprintf("UNIQUE (%s)", SnakeCase(field.Name)))
 	}
 }
 
 func (t *TableSchema) WriteCompositeUnique() {
-	for _, fields := range t.CompositeIndexes {
+	names := make([]string, 0, len(t.CompositeIndexes))
+	for name := range t.CompositeIndexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fields := t.CompositeIndexes[name]
 		uniqueIndexes := []string{}
 		for _, field := range fields {
 			uniqueIndexes = append(uniqueIndexes, SnakeCase(field.Name))
@@ -99,88 +348,191 @@ func (t *TableSchema) WriteCompositeUnique() {
 
 }
 
-// Returns the sql string for creating the table
-func (table *TableSchema) InsertSchema(v interface{}, dialect string) (string, []interface{}) {
+// Returns the sql string for inserting v, a struct or pointer to a struct, into the table.
+// returning lists the columns of the RETURNING clause and defaults to *
+func (table *TableSchema) InsertSchema(v interface{}, dialect string, returning ...string) (string, []interface{}) {
+	return table.insertSchema(v, dialect, false, true, returning...)
+}
+
+// Same as InsertSchema but rows that conflict with an existing row are skipped
+// with ON CONFLICT DO NOTHING, or INSERT IGNORE for mysql.
+// No row is returned for a skipped row.
+func (table *TableSchema) InsertIfNotExistsSchema(v interface{}, dialect string, returning ...string) (string, []interface{}) {
+	return table.insertSchema(v, dialect, true, true, returning...)
+}
+
+// Same as InsertSchema but without a RETURNING clause, for statements that can't return rows
+// e.g inserting into a view. Conflicting rows are skipped if ignoreConflicts is true.
+func (table *TableSchema) InsertSchemaNoReturning(v interface{}, dialect string, ignoreConflicts bool) (string, []interface{}) {
+	return table.insertSchema(v, dialect, ignoreConflicts, false)
+}
+
+func (table *TableSchema) insertSchema(v interface{}, dialect string, ignoreConflicts, withReturning bool, returning ...string) (string, []interface{}) {
 	buf := strings.Builder{}
 	values := []interface{}{}
-	buf.WriteString(fmt.Sprintf("INSERT INTO %s (", table.TableName))
-	pkSkipped := false
+	columns := []string{}
+	placeholders := []string{}
 
-	for i, field := range table.Fields {
-		if field.IsForeignKey() {
+	for _, field := range table.Fields {
+		if field.IsRelation() {
 			continue
 		}
 
-		if i > 0 {
-			if !(pkSkipped && i == 1) {
-				// If we have skipped the primary key, and we are on the second field,
-				buf.WriteString(", ")
-			}
-		}
-
-		refObjVal := reflect.ValueOf(v).Elem().FieldByName(field.Name)
-		if field.IsPrimaryKey() && reflect.Zero(refObjVal.Type()).Interface() == refObjVal.Interface() {
-			pkSkipped = true
+		// Let the database generate zero valued auto increment columns.
+		// Other columns, including uuid and natural primary keys, are always inserted.
+		refObjVal := reflect.Indirect(reflect.ValueOf(v)).FieldByName(field.Name)
+		if field.IsSerial() && refObjVal.IsZero() || field.IsComputed() {
 			continue
 		}
 
-		buf.WriteString(SnakeCase(field.Name))
-		values = append(values, refObjVal.Interface())
+		columns = append(columns, SnakeCase(field.Name))
+		placeholders = append(placeholders, dialectFor(dialect).Placeholder(len(placeholders)+1))
+		values = append(values, columnValue(refObjVal))
+	}
+
+	insert := "INSERT"
+	if ignoreConflicts && dialect == "mysql" {
+		insert = "INSERT IGNORE"
+	}
+
+	buf.WriteString(fmt.Sprintf("%s INTO %s (%s) VALUES (%s", insert, table.QualifiedName(),
+		strings.Join(columns, ", "), strings.Join(placeholders, ", ")))
+	buf.WriteString(")")
+
+	if ignoreConflicts && dialect != "mysql" {
+		buf.WriteString(" ON CONFLICT DO NOTHING")
 	}
 
-	buf.WriteString(") VALUES (")
+	// Add returning clause
+	if withReturning && dialect == "postgres" {
+		buf.WriteString(ReturningClause(returning))
+	}
 
-	// Loop through the fields and build the sql.
-	// Initialize index (not loop index) to control i in the for loop
-	i := 0
+	return buf.String(), values
+}
+
+// Returns the columns inserted for rows, a slice of struct values of the table.
+// Generated columns are left out if they are zero in all rows.
+func (table *TableSchema) InsertColumns(rows []reflect.Value) []*Field {
+	fields := []*Field{}
 	for _, field := range table.Fields {
-		if (field.IsPrimaryKey() && pkSkipped) || field.IsForeignKey() {
+		if field.IsRelation() || field.IsComputed() {
 			continue
 		}
 
+		include := !field.IsSerial()
+		for i := 0; i < len(rows) && !include; i++ {
+			include = !rows[i].FieldByName(field.Name).IsZero()
+		}
+
+		if include {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Returns the sql string for inserting rows, a slice of struct values, with a single
+// multi-row INSERT statement that updates the updateColumns of rows conflicting on conflictColumns.
+//
+// Zero valued generated columns are written as DEFAULT. If updateColumns is empty,
+// conflicting rows are skipped with DO NOTHING. All columns of the rows are returned.
+// The placeholders are those of dialect.
+func (table *TableSchema) UpsertSchema(rows []reflect.Value, fields []*Field, dialect string, conflictColumns, updateColumns []string) (string, []interface{}) {
+	buf := strings.Builder{}
+	values := []interface{}{}
+
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = SnakeCase(field.Name)
+	}
+
+	buf.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES ", table.QualifiedName(), strings.Join(columns, ", ")))
+
+	for i, row := range rows {
 		if i > 0 {
 			buf.WriteString(", ")
 		}
 
-		buf.WriteString(fmt.Sprintf("$%d", i+1))
-		i++
+		placeholders := make([]string, len(fields))
+		for j, field := range fields {
+			value := row.FieldByName(field.Name)
+			if field.IsSerial() && value.IsZero() {
+				placeholders[j] = "DEFAULT"
+				continue
+			}
 
-	}
+			values = append(values, columnValue(value))
+			placeholders[j] = dialectFor(dialect).Placeholder(len(values))
+		}
 
-	buf.WriteString(")")
+		buf.WriteString("(" + strings.Join(placeholders, ", ") + ")")
+	}
 
-	// Add returning clause
-	if dialect == "postgres" {
-		buf.WriteString(" RETURNING *")
+	conflict := make([]string, len(conflictColumns))
+	for i, column := range conflictColumns {
+		conflict[i] = SnakeCase(column)
+	}
+	buf.WriteString(fmt.Sprintf(" ON CONFLICT (%s)", strings.Join(conflict, ", ")))
+
+	if len(updateColumns) == 0 {
+		buf.WriteString(" DO NOTHING")
+	} else {
+		updates := make([]string, len(updateColumns))
+		for i, column := range updateColumns {
+			column = SnakeCase(column)
+			updates[i] = fmt.Sprintf("%s = EXCLUDED.%s", column, column)
+		}
+		buf.WriteString(" DO UPDATE SET " + strings.Join(updates, ", "))
 	}
 
+	buf.WriteString(" RETURNING *")
 	return buf.String(), values
 }
 
-// Returns the sql string for updating the table
+// Returns the value of the column v for a query argument.
+// Nil pointers are returned as an untyped nil, so they are inserted as NULL.
+func columnValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// Returns the RETURNING clause with a leading empty space for the columns.
+// Column names are converted to snake_case. If columns is empty, all columns(*) are returned.
+func ReturningClause(columns []string) string {
+	if len(columns) == 0 {
+		return " RETURNING *"
+	}
+
+	snakeColumns := make([]string, len(columns))
+	for i, column := range columns {
+		snakeColumns[i] = SnakeCase(column)
+	}
+
+	return " RETURNING " + strings.Join(snakeColumns, ", ")
+}
+
+// Returns the sql string for updating the table with v, a struct or pointer to a struct
 func (table *TableSchema) UpdateSchema(v interface{}, dialect string) (string, []interface{}) {
 	buf := strings.Builder{}
 	values := []interface{}{}
-	buf.WriteString(fmt.Sprintf("UPDATE %s SET ", table.TableName))
+	buf.WriteString(fmt.Sprintf("UPDATE %s SET ", table.QualifiedName()))
 
-	// Loop through the fields and build the sql.
-	// Initialize index (not loop index) to control i in the for loop
-	i := 0
-	for loopIndex, field := range table.Fields {
-		if field.IsPrimaryKey() || field.IsForeignKey() {
+	// Computed columns are written by the database
+	assignments := []string{}
+	for _, field := range table.Fields {
+		if field.IsPrimaryKey() || field.IsRelation() || field.IsComputed() {
 			continue
 		}
 
-		if i > 0 && loopIndex < len(table.Fields)-1 {
-			buf.WriteString(", ")
-		}
-
-		buf.WriteString(fmt.Sprintf("%s = $%d", SnakeCase(field.Name), i+1))
-		refObjVal := reflect.ValueOf(v).Elem().FieldByName(field.Name)
-		values = append(values, refObjVal.Interface())
-		i++
+		refObjVal := reflect.Indirect(reflect.ValueOf(v)).FieldByName(field.Name)
+		values = append(values, columnValue(refObjVal))
+		assignments = append(assignments, fmt.Sprintf("%s = %s", SnakeCase(field.Name), dialectFor(dialect).Placeholder(len(values))))
 	}
 
+	buf.WriteString(strings.Join(assignments, ", "))
 	return buf.String(), values
 
 }
@@ -188,7 +540,7 @@ func (table *TableSchema) UpdateSchema(v interface{}, dialect string) (string, [
 // Returns the sql string for deleting the table with a trailing empty space
 // Warning: Does not include the where clause
 func (table *TableSchema) DeleteSchema(dialect string) string {
-	return fmt.Sprintf("DELETE FROM %s ", table.TableName)
+	return fmt.Sprintf("DELETE FROM %s ", table.QualifiedName())
 }
 
 func (fk *ForeignKey) String() string {
@@ -204,5 +556,272 @@ func (fk *ForeignKey) String() string {
 		sql += fk.OnUpdate
 	}
 
+	if fk.Deferrable {
+		sql += " DEFERRABLE INITIALLY DEFERRED"
+	}
+
+	return sql
+}
+
+// Returns the sql string for creating the index
+func (idx *Index) String() string {
+	sql := "CREATE "
+	if idx.Unique {
+		sql += "UNIQUE "
+	}
+
+	sql += fmt.Sprintf("INDEX IF NOT EXISTS %s ON %s", QuoteIdentifier(idx.Name, idx.dialect), idx.TableName)
+	if idx.Method != "" {
+		sql += " USING " + idx.Method
+	}
+
+	sql += fmt.Sprintf(" (%s)", strings.Join(idx.Columns, ", "))
+	if idx.Where != "" {
+		sql += " WHERE " + idx.Where
+	}
+
 	return sql
 }
+
+// Builds the table indexes from the index tags of the fields.
+//
+// The tag is of the form index, index:name, index:gin or index:name,gin.
+// The options sort:desc and where:<condition> create descending and partial indexes
+// e.g index:idx_active_users,where:status='active'. The where option must come last.
+// Fields sharing an index name are indexed together in field order.
+// GIN indexes are only allowed on jsonb, array and tsvector columns.
+func (t *TableSchema) parseIndexes() error {
+	indexes := map[string]*Index{}
+
+	for _, field := range t.Fields {
+		v, ok := field.Tags["index"]
+		if !ok {
+			continue
+		}
+
+		column := SnakeCase(field.Name)
+		name, method, order, where := "", "", "", ""
+		parts := strings.Split(v, ",")
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			key, value, _ := strings.Cut(part, ":")
+
+			if strings.EqualFold(part, "gin") {
+				method = "GIN"
+			} else if strings.EqualFold(key, "sort") {
+				order = strings.ToUpper(strings.TrimSpace(value))
+				if order != "ASC" && order != "DESC" {
+					return fmt.Errorf("invalid sort %q for index on %s.%s: must be asc or desc", value, t.TableName, column)
+				}
+			} else if strings.EqualFold(key, "where") {
+				// The condition may contain commas e.g status IN ('a', 'b')
+				where = strings.TrimSpace(strings.Join(append([]string{value}, parts[i+1:]...), ","))
+				if where == "" {
+					return fmt.Errorf("empty where condition for index on %s.%s", t.TableName, column)
+				}
+				break
+			} else if part != "" {
+				name = part
+			}
+		}
+
+		if method == "GIN" && !field.SupportsGIN() {
+			return fmt.Errorf("GIN index on %s.%s requires a jsonb, array or tsvector column", t.TableName, column)
+		}
+
+		if name == "" {
+			name = fmt.Sprintf("idx_%s_%s", unqualifiedName(t.TableName), column)
+		}
+
+		idx, exists := indexes[name]
+		if !exists {
+			idx = &Index{Name: name, TableName: t.QualifiedName(), dialect: t.dialect}
+			indexes[name] = idx
+			t.Indexes = append(t.Indexes, idx)
+		}
+
+		if method != "" {
+			idx.Method = method
+		}
+
+		if where != "" {
+			idx.Where = where
+		}
+
+		if order != "" {
+			column += " " + order
+		}
+
+		idx.Columns = append(idx.Columns, column)
+	}
+
+	t.parseUniqueIndexes()
+	return nil
+}
+
+// Name of the column of soft deleted tables
+const softDeleteColumn = "deleted_at"
+
+// Returns the deleted_at field of tables with soft deletes or nil
+func (t *TableSchema) SoftDeleteField() *Field {
+	return t.FieldByColumn(softDeleteColumn)
+}
+
+// Replaces the uniqueIndex constraints with unique indexes if the UniqueIndexes option is set.
+//
+// The constraints of soft deleted tables are always replaced with partial unique indexes
+// on the rows that are not deleted, so values of deleted rows can be inserted again.
+// The index of uniqueIndex:uq_email on users is named users_uq_email.
+func (t *TableSchema) parseUniqueIndexes() {
+	where := ""
+	if t.SoftDeleteField() != nil {
+		where = softDeleteColumn + " IS NULL"
+	} else if !t.opts.UniqueIndexes {
+		return
+	}
+
+	names := make([]string, 0, len(t.CompositeIndexes))
+	for name := range t.CompositeIndexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		columns := []string{}
+		for _, field := range t.CompositeIndexes[name] {
+			columns = append(columns, SnakeCase(field.Name))
+		}
+
+		// Index names are unique per schema, unlike the names in CompositeIndexes which
+		// are per table. Prefix them with the table name so that two tables can use the same name.
+		// The index is created in the schema of the table, so the name has no schema.
+		tableName := unqualifiedName(t.TableName)
+		indexName := name
+		if indexName == "" {
+			indexName = fmt.Sprintf("uidx_%s_%s", tableName, strings.Join(columns, "_"))
+		} else if !strings.HasPrefix(indexName, tableName+"_") {
+			indexName = tableName + "_" + indexName
+		}
+
+		t.Indexes = append(t.Indexes, &Index{
+			Name:      indexName,
+			TableName: t.QualifiedName(),
+			Columns:   columns,
+			Unique:    true,
+			Where:     where,
+			dialect:   t.dialect,
+		})
+		delete(t.CompositeIndexes, name)
+	}
+}
+
+// Returns the sql string for creating the enum type.
+// Postgres has no CREATE TYPE IF NOT EXISTS, the caller should ignore "already exists" errors.
+func (e *EnumType) String() string {
+	values := make([]string, len(e.Values))
+	for i, value := range e.Values {
+		values[i] = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+
+	return fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", QualifyName(e.Schema, e.Name), strings.Join(values, ", "))
+}
+
+// Returns the sql string for creating the postgres schema if it does not exist
+func CreateSchemaSQL(schemaName string) string {
+	return "CREATE SCHEMA IF NOT EXISTS " + QuoteIdentifier(schemaName, "postgres")
+}
+
+// Matches extension names e.g pgcrypto or uuid-ossp
+var extensionNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Extensions providing the functions used in column defaults
+var defaultFuncExtensions = map[string]string{
+	"gen_random_uuid(":  "pgcrypto",
+	"uuid_generate_v1(": "uuid-ossp",
+	"uuid_generate_v4(": "uuid-ossp",
+}
+
+// Returns the sql string for creating the postgres extension if it does not exist.
+// Returns an error if name is not a valid extension name.
+func CreateExtensionSQL(name string) (string, error) {
+	if !extensionNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid extension name %q", name)
+	}
+	return "CREATE EXTENSION IF NOT EXISTS " + QuoteIdentifier(name, "postgres"), nil
+}
+
+// Returns the sorted names of the extensions needed by the column defaults of the table
+// e.g pgcrypto for gen_random_uuid()
+func (t *TableSchema) Extensions() []string {
+	found := map[string]bool{}
+	for _, field := range t.Fields {
+		def := strings.ToLower(field.Tags["default"])
+		for fn, extension := range defaultFuncExtensions {
+			if strings.Contains(def, fn) {
+				found[extension] = true
+			}
+		}
+	}
+
+	extensions := make([]string, 0, len(found))
+	for extension := range found {
+		extensions = append(extensions, extension)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// Returns the statements creating the custom sql types of the table columns
+// e.g the composite type of datatypes.Money. Enum types are in Enums.
+func (t *TableSchema) CreateTypeStatements() []string {
+	statements := []string{}
+	for _, customType := range t.customTypes() {
+		statements = append(statements, customType.SQL)
+	}
+	return statements
+}
+
+// Returns the statements creating the custom sql types of the table columns.
+// The types are created in the default schema and named by their SQLType.
+func (t *TableSchema) customTypes() []migrationStatement {
+	statements := []migrationStatement{}
+	for _, field := range t.Fields {
+		if field.IsRelation() {
+			continue
+		}
+
+		fieldType := field.ReflectObjType.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		creator, ok := reflect.New(fieldType).Interface().(typeCreator)
+		if !ok {
+			continue
+		}
+
+		statement := migrationStatement{SQL: creator.CreateTypeSQL(), Kind: typeStatement}
+		if sqlType, ok := customSQLType(fieldType); ok {
+			statement.Name = QualifyName(DefaultSchema, sqlType)
+		}
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// Adds the enum type of field to the table enums if the field is an enum
+func (t *TableSchema) addEnum(field *Field) {
+	typ, enum, ok := enumType(field.ReflectObjType.Type)
+	if !ok {
+		return
+	}
+
+	name := EnumTypeName(typ)
+	for _, e := range t.Enums {
+		if e.Name == name {
+			return
+		}
+	}
+
+	t.Enums = append(t.Enums, &EnumType{Name: name, Schema: t.Schema, Values: enum.EnumValues()})
+}
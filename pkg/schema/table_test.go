@@ -0,0 +1,578 @@
+package schema
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/abiiranathan/gosqlorm/pkg/datatypes"
+	"github.com/abiiranathan/gosqlorm/pkg/models"
+	"github.com/lib/pq"
+)
+
+// Returns the CREATE INDEX statements of the table schema of model parsed with opts
+func indexStatements(t *testing.T, model interface{}, opts Options) []string {
+	t.Helper()
+
+	tblSchema, err := GetTableSchemaWithOptions(model, "postgres", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements := []string{}
+	for _, idx := range tblSchema.Indexes {
+		statements = append(statements, idx.String())
+	}
+	return statements
+}
+
+// Fails t if got and want are not the same statements
+func assertStatements(t *testing.T, got, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d statements %q, want %d %q", len(got), got, len(want), want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("statement %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+type ginArticle struct {
+	ID   int            `orm:"primaryKey;autoIncrement"`
+	Tags pq.StringArray `orm:"index:gin"`
+	Data datatypes.JSON `orm:"type:jsonb;index:idx_article_data,gin"`
+}
+
+type ginInvalid struct {
+	ID   int    `orm:"primaryKey;autoIncrement"`
+	Name string `orm:"index:gin"`
+}
+
+func TestGINIndex(t *testing.T) {
+	assertStatements(t, indexStatements(t, &ginArticle{}, Options{}), []string{
+		"CREATE INDEX IF NOT EXISTS idx_gin_articles_tags ON public.gin_articles USING GIN (tags)",
+		"CREATE INDEX IF NOT EXISTS idx_article_data ON public.gin_articles USING GIN (data)",
+	})
+
+	if _, err := GetTableSchema(&ginInvalid{}, "postgres"); err == nil {
+		t.Error("GIN index on a varchar column returned no error")
+	}
+}
+
+type sortedPost struct {
+	ID        int    `orm:"primaryKey;autoIncrement"`
+	Title     string `orm:"index:sort:desc"`
+	Status    string `orm:"index:idx_published_posts,where:status IN ('draft', 'published')"`
+	AuthorID  int    `orm:"index:idx_author_created"`
+	CreatedAt string `orm:"index:idx_author_created,sort:desc"`
+}
+
+type invalidSort struct {
+	ID    int    `orm:"primaryKey;autoIncrement"`
+	Title string `orm:"index:sort:up"`
+}
+
+type emptyWhere struct {
+	ID    int    `orm:"primaryKey;autoIncrement"`
+	Title string `orm:"index:where:"`
+}
+
+func TestIndexSortAndWhere(t *testing.T) {
+	assertStatements(t, indexStatements(t, &sortedPost{}, Options{}), []string{
+		"CREATE INDEX IF NOT EXISTS idx_sorted_posts_title ON public.sorted_posts (title DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_published_posts ON public.sorted_posts (status) WHERE status IN ('draft', 'published')",
+		"CREATE INDEX IF NOT EXISTS idx_author_created ON public.sorted_posts (author_id, created_at DESC)",
+	})
+
+	if _, err := GetTableSchema(&invalidSort{}, "postgres"); err == nil {
+		t.Error("index with sort:up returned no error")
+	}
+
+	if _, err := GetTableSchema(&emptyWhere{}, "postgres"); err == nil {
+		t.Error("index with an empty where condition returned no error")
+	}
+}
+
+type softDeletedMember struct {
+	ID        int    `orm:"primaryKey;autoIncrement"`
+	Email     string `orm:"uniqueIndex:uq_email"`
+	OrgID     int    `orm:"uniqueIndex:uq_org_code"`
+	Code      string `orm:"uniqueIndex:uq_org_code"`
+	DeletedAt *string
+}
+
+func TestSoftDeleteUniqueIndexes(t *testing.T) {
+	assertStatements(t, indexStatements(t, &softDeletedMember{}, Options{}), []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS soft_deleted_members_uq_email ON public.soft_deleted_members (email) WHERE deleted_at IS NULL",
+		"CREATE UNIQUE INDEX IF NOT EXISTS soft_deleted_members_uq_org_code ON public.soft_deleted_members (org_id, code) WHERE deleted_at IS NULL",
+	})
+
+	// The unique constraints are replaced by the indexes
+	tblSchema, err := GetTableSchema(&softDeletedMember{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sql := tblSchema.String("postgres"); strings.Contains(sql, "UNIQUE") {
+		t.Errorf("table of a soft deleted model has unique constraints:\n%s", sql)
+	}
+}
+
+type compositeAccount struct {
+	ID    int    `orm:"primaryKey;autoIncrement"`
+	OrgID int    `orm:"uniqueIndex"`
+	Code  string `orm:"uniqueIndex"`
+	Email string `orm:"uniqueIndex:uq_email"`
+}
+
+func TestUniqueIndexesOption(t *testing.T) {
+	// Without the option, composite uniques are table constraints
+	tblSchema, err := GetTableSchema(&compositeAccount{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "CREATE TABLE IF NOT EXISTS public.composite_accounts (\n" +
+		"  id SERIAL ,\n" +
+		"  org_id INTEGER,\n" +
+		"  code VARCHAR(255),\n" +
+		"  email VARCHAR(255),\n" +
+		"PRIMARY KEY (id),\n" +
+		"UNIQUE(org_id, code),\n" +
+		"UNIQUE(email)\n" +
+		");"
+	if got := tblSchema.String("postgres"); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+
+	opts := Options{UniqueIndexes: true}
+	assertStatements(t, indexStatements(t, &compositeAccount{}, opts), []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS uidx_composite_accounts_org_id_code ON public.composite_accounts (org_id, code)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS composite_accounts_uq_email ON public.composite_accounts (email)",
+	})
+}
+
+type qualifiedAccount struct {
+	ID    int    `orm:"primaryKey;autoIncrement"`
+	OrgID int    `orm:"uniqueIndex:uq_org_code"`
+	Code  string `orm:"uniqueIndex:uq_org_code"`
+	Email string `orm:"uniqueIndex:UQ_Email"`
+	Name  string `orm:"index"`
+}
+
+func (qualifiedAccount) TableName() string { return "app.accounts" }
+
+func TestIndexNamesOfQualifiedTables(t *testing.T) {
+	// Index names use the table name without its schema and are quoted if needed
+	assertStatements(t, indexStatements(t, &qualifiedAccount{}, Options{UniqueIndexes: true}), []string{
+		"CREATE INDEX IF NOT EXISTS idx_accounts_name ON app.accounts (name)",
+		`CREATE UNIQUE INDEX IF NOT EXISTS "accounts_UQ_Email" ON app.accounts (email)`,
+		"CREATE UNIQUE INDEX IF NOT EXISTS accounts_uq_org_code ON app.accounts (org_id, code)",
+	})
+}
+
+type namedUniqueUser struct {
+	ID       int    `orm:"primaryKey;autoIncrement"`
+	Email    string `orm:"unique:uq_users_email"`
+	Username string `orm:"unique:UQ_Username"`
+	Phone    string `orm:"unique"`
+}
+
+func TestNamedUniqueConstraints(t *testing.T) {
+	tblSchema, err := GetTableSchema(&namedUniqueUser{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sql := tblSchema.String("postgres")
+	for _, constraint := range []string{
+		"CONSTRAINT uq_users_email UNIQUE (email)",
+		`CONSTRAINT "UQ_Username" UNIQUE (username)`,
+		",\nUNIQUE (phone)",
+	} {
+		if !strings.Contains(sql, constraint) {
+			t.Errorf("table has no %s:\n%s", constraint, sql)
+		}
+	}
+
+	want := `ALTER TABLE public.named_unique_users ADD COLUMN IF NOT EXISTS username VARCHAR(255) CONSTRAINT "UQ_Username" UNIQUE;`
+	if got := tblSchema.AddColumnSchema(tblSchema.FieldByColumn("username")); got != want {
+		t.Errorf("AddColumnSchema(username) = %s, want %s", got, want)
+	}
+}
+
+type orderStatus string
+
+func (orderStatus) EnumValues() []string { return []string{"pending", "paid"} }
+
+type enumOrder struct {
+	ID       int         `orm:"primaryKey;autoIncrement"`
+	Status   orderStatus `orm:"not null"`
+	Previous *orderStatus
+}
+
+func TestEnumColumns(t *testing.T) {
+	tblSchema, err := GetTableSchema(&enumOrder{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The nullable enum uses the same type
+	if len(tblSchema.Enums) != 1 {
+		t.Fatalf("got %d enums, want 1", len(tblSchema.Enums))
+	}
+
+	want := "CREATE TYPE public.order_status AS ENUM ('pending', 'paid')"
+	if got := tblSchema.Enums[0].String(); got != want {
+		t.Errorf("enum = %s, want %s", got, want)
+	}
+
+	sql := tblSchema.String("postgres")
+	for _, column := range []string{"status PUBLIC.ORDER_STATUS not null", "previous PUBLIC.ORDER_STATUS,"} {
+		if !strings.Contains(sql, column) {
+			t.Errorf("table has no column %s:\n%s", column, sql)
+		}
+	}
+}
+
+func TestForeignKeysPerSchema(t *testing.T) {
+	ClearSchemaCache()
+
+	// The same models parsed in two schemas have the same constraint names
+	tables := map[string][]*TableSchema{}
+	for _, schemaName := range []string{"app", ""} {
+		for _, model := range []interface{}{&models.User{}, &models.Token{}, &models.UserProfile{}, &models.Contact{}} {
+			tblSchema, err := GetTableSchemaWithOptions(model, "postgres", Options{Schema: schemaName})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tables[schemaName] = append(tables[schemaName], tblSchema)
+		}
+	}
+
+	for _, tableName := range []string{"app.tokens", "public.tokens"} {
+		if len(ForeignKeys[tableName]) != 1 {
+			t.Errorf("%d foreign keys registered for %s, want 1", len(ForeignKeys[tableName]), tableName)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := DumpTables(&buf, "postgres", tables[""]...); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tableName := range []string{"public.tokens", "public.user_profiles", "public.contacts"} {
+		if !strings.Contains(buf.String(), "ALTER TABLE "+tableName+" ADD CONSTRAINT") {
+			t.Errorf("foreign key of %s is missing in:\n%s", tableName, buf.String())
+		}
+	}
+}
+
+type quotedOrder struct {
+	ID  int    `orm:"primaryKey;autoIncrement"`
+	Ref string `orm:"unique:Order_Ref;index"`
+}
+
+func (quotedOrder) TableName() string { return "order" }
+
+func TestQuotedIdentifiersPerDialect(t *testing.T) {
+	tests := []struct {
+		dialect    string
+		table      string
+		constraint string
+		index      string
+	}{
+		{"postgres", `public."order"`, `CONSTRAINT "Order_Ref" UNIQUE (ref)`, `CREATE INDEX IF NOT EXISTS idx_order_ref ON public."order" (ref)`},
+		{"mysql", "`order`", "CONSTRAINT `Order_Ref` UNIQUE (ref)", "CREATE INDEX IF NOT EXISTS idx_order_ref ON `order` (ref)"},
+		{"sqlite", `"order"`, `CONSTRAINT "Order_Ref" UNIQUE (ref)`, `CREATE INDEX IF NOT EXISTS idx_order_ref ON "order" (ref)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			tblSchema, err := GetTableSchema(&quotedOrder{}, tt.dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tblSchema.QualifiedName() != tt.table {
+				t.Errorf("QualifiedName() = %s, want %s", tblSchema.QualifiedName(), tt.table)
+			}
+
+			sql := tblSchema.String(tt.dialect)
+			if !strings.HasPrefix(sql, "CREATE TABLE IF NOT EXISTS "+tt.table+" (") || !strings.Contains(sql, tt.constraint) {
+				t.Errorf("String(%s) = %s, want table %s with %s", tt.dialect, sql, tt.table, tt.constraint)
+			}
+
+			assertStatements(t, []string{tblSchema.Indexes[0].String()}, []string{tt.index})
+		})
+	}
+}
+
+type fkActionChild struct {
+	ID       int `orm:"primaryKey;autoIncrement"`
+	ParentID int
+}
+
+type fkCascadeParent struct {
+	ID       int             `orm:"primaryKey;autoIncrement"`
+	Children []fkActionChild `orm:"foreignKey:ParentID->ID;onDelete:set null;onUpdate:Cascade"`
+}
+
+type fkNoActionParent struct {
+	ID       int             `orm:"primaryKey;autoIncrement"`
+	Children []fkActionChild `orm:"foreignKey:ParentID->ID;onUpdate:no  action"`
+}
+
+type fkInvalidDeleteParent struct {
+	ID       int             `orm:"primaryKey;autoIncrement"`
+	Children []fkActionChild `orm:"foreignKey:ParentID->ID;onDelete:drop"`
+}
+
+type fkInvalidUpdateParent struct {
+	ID       int             `orm:"primaryKey;autoIncrement"`
+	Children []fkActionChild `orm:"foreignKey:ParentID->ID;onUpdate:set"`
+}
+
+type fkDeferredParent struct {
+	ID       int             `orm:"primaryKey;autoIncrement"`
+	Children []fkActionChild `orm:"foreignKey:ParentID->ID;onDelete:RESTRICT;deferrable"`
+}
+
+func TestForeignKeyActions(t *testing.T) {
+	tests := []struct {
+		model interface{}
+		want  string
+	}{
+		{
+			&fkCascadeParent{},
+			"ALTER TABLE public.fk_action_children ADD CONSTRAINT fk_cascade_parents_children_fkey " +
+				"FOREIGN KEY (parent_id) REFERENCES public.fk_cascade_parents (id) ON DELETE SET NULL ON UPDATE CASCADE",
+		},
+		{
+			&fkNoActionParent{},
+			"ALTER TABLE public.fk_action_children ADD CONSTRAINT fk_no_action_parents_children_fkey " +
+				"FOREIGN KEY (parent_id) REFERENCES public.fk_no_action_parents (id) ON UPDATE NO ACTION",
+		},
+		{
+			&fkDeferredParent{},
+			"ALTER TABLE public.fk_action_children ADD CONSTRAINT fk_deferred_parents_children_fkey " +
+				"FOREIGN KEY (parent_id) REFERENCES public.fk_deferred_parents (id) ON DELETE RESTRICT DEFERRABLE INITIALLY DEFERRED",
+		},
+	}
+
+	for _, tt := range tests {
+		tblSchema, err := GetTableSchema(tt.model, "postgres")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		statements := []string{}
+		for _, fk := range tblSchema.ForeignKeys {
+			statements = append(statements, fk.String())
+		}
+		assertStatements(t, statements, []string{tt.want})
+	}
+
+	for _, model := range []interface{}{&fkInvalidDeleteParent{}, &fkInvalidUpdateParent{}} {
+		if _, err := GetTableSchema(model, "postgres"); err == nil {
+			t.Errorf("%T with an invalid referential action returned no error", model)
+		}
+	}
+}
+
+type compositeKeyMember struct {
+	OrgID  int `orm:"primaryKey"`
+	UserID int `orm:"primaryKey"`
+	Role   string
+}
+
+type singleKeyLog struct {
+	ID      int `orm:"primaryKey;autoIncrement"`
+	Message string
+}
+
+type noKeyLog struct {
+	Message string
+}
+
+func TestPrimaryKeyField(t *testing.T) {
+	tests := []struct {
+		model interface{}
+		want  []string
+	}{
+		{&singleKeyLog{}, []string{"ID"}},
+		{&compositeKeyMember{}, []string{"OrgID", "UserID"}},
+		{&noKeyLog{}, []string{}},
+	}
+
+	for _, tt := range tests {
+		// The table sql is not generated first
+		tblSchema, err := GetTableSchema(tt.model, "postgres")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names := []string{}
+		for _, field := range tblSchema.PrimaryKeyField() {
+			names = append(names, field.Name)
+		}
+
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("%T.PrimaryKeyField() = %v, want %v", tt.model, names, tt.want)
+		}
+	}
+}
+
+type invalidForeignKeyUser struct {
+	ID      int                `orm:"primaryKey;autoIncrement"`
+	Profile models.UserProfile `orm:"foreignKey:UserID"`
+}
+
+func TestParseConstraints(t *testing.T) {
+	// The keys are parsed without generating the table sql
+	user, err := GetTableSchema(&models.User{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.PrimaryKey == nil || user.PrimaryKey.Name != "ID" {
+		t.Errorf("PrimaryKey = %v, want ID", user.PrimaryKey)
+	}
+
+	if n := len(user.CompositeIndexes["username_index"]); n != 2 {
+		t.Errorf("username_index has %d fields, want 2", n)
+	}
+
+	if len(user.ForeignKeys) != 3 {
+		t.Errorf("got %d foreign keys, want 3", len(user.ForeignKeys))
+	}
+
+	profile, err := GetTableSchema(&models.UserProfile{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(profile.UniqueFields) != 1 || profile.UniqueFields[0].Name != "UserID" {
+		t.Errorf("UniqueFields = %v, want UserID", profile.UniqueFields)
+	}
+
+	if _, err := GetTableSchema(&invalidForeignKeyUser{}, "postgres"); err == nil {
+		t.Error("foreign key without a parent key returned no error")
+	}
+}
+
+type spacedKeyParent struct {
+	ID       int             `orm:"primaryKey;autoIncrement"`
+	Children []fkActionChild `orm:"foreignKey: ParentID -> ID ;onDelete:cascade"`
+}
+
+type emptyKeyParent struct {
+	ID       int             `orm:"primaryKey;autoIncrement"`
+	Children []fkActionChild `orm:"foreignKey: -> ID"`
+}
+
+func TestSpacedForeignKeyTags(t *testing.T) {
+	tblSchema, err := GetTableSchema(&spacedKeyParent{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fk := range tblSchema.ForeignKeys {
+		if fk.FK != "ParentID" || fk.ParentPkColumn != "ID" {
+			t.Errorf("foreign key columns = %q -> %q, want ParentID -> ID", fk.FK, fk.ParentPkColumn)
+		}
+
+		if !strings.Contains(fk.String(), "FOREIGN KEY (parent_id) REFERENCES public.spaced_key_parents (id) ON DELETE CASCADE") {
+			t.Errorf("foreign key = %s", fk.String())
+		}
+	}
+
+	if _, err := GetTableSchema(&emptyKeyParent{}, "postgres"); err == nil {
+		t.Error("foreign key tag without a child key returned no error")
+	}
+}
+
+type searchArticle struct {
+	ID     int `orm:"primaryKey;autoIncrement"`
+	Title  string
+	Body   string
+	Search datatypes.TSVector `orm:"generated:to_tsvector('english', title || ' ' || body);index:gin"`
+}
+
+func TestTSVectorColumns(t *testing.T) {
+	assertStatements(t, indexStatements(t, &searchArticle{}, Options{}), []string{
+		"CREATE INDEX IF NOT EXISTS idx_search_articles_search ON public.search_articles USING GIN (search)",
+	})
+
+	tblSchema, err := GetTableSchema(&searchArticle{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "search TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', title || ' ' || body)) STORED"
+	if sql := tblSchema.String("postgres"); !strings.Contains(sql, want) {
+		t.Errorf("table has no column %s:\n%s", want, sql)
+	}
+}
+
+type flushedAccount struct {
+	ID    int `orm:"primaryKey;autoIncrement"`
+	Email string
+}
+
+func TestFlush(t *testing.T) {
+	tblSchema, err := GetTableSchema(&flushedAccount{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := tblSchema.String("postgres")
+	tblSchema.Flush()
+	if tblSchema.migrated {
+		t.Error("Flush did not reset the migrated flag")
+	}
+
+	// The columns are written again from empty buffers
+	if got := tblSchema.String("postgres"); got != want {
+		t.Errorf("String() after Flush = %s, want %s", got, want)
+	}
+
+	if n := strings.Count(tblSchema.String("postgres"), "email"); n != 1 {
+		t.Errorf("table has %d email columns, want 1", n)
+	}
+}
+
+type relationFirstAccount struct {
+	Children []fkActionChild `orm:"foreignKey:ParentID->ID"`
+	ID       int             `orm:"primaryKey;autoIncrement"`
+	Email    string
+	Tokens   []fkActionChild `orm:"foreignKey:ParentID->ID"`
+	Name     string
+}
+
+func TestWriteColumnsSkipsForeignKeys(t *testing.T) {
+	tblSchema, err := GetTableSchema(&relationFirstAccount{}, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tblSchema.buf = &bytes.Buffer{}
+	tblSchema.WriteColumns("postgres")
+
+	columns := strings.Split(tblSchema.buf.String(), ",\n")
+	want := []string{"  id ", "  email ", "  name "}
+	if len(columns) != len(want) {
+		t.Fatalf("WriteColumns() = %q, want columns %q", tblSchema.buf.String(), want)
+	}
+
+	for i, column := range columns {
+		if !strings.HasPrefix(column, want[i]) {
+			t.Errorf("column %d = %q, want %q", i, column, want[i])
+		}
+	}
+}